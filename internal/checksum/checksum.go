@@ -0,0 +1,98 @@
+// Package checksum records and verifies the state of data files
+//
+// A file's state is its size, modification time and SHA-256 digest.
+// Verification always re-hashes the content, so a file rewritten with
+// its old size and mtime is still reported as modified.
+package checksum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"srdm/internal/model"
+	"time"
+)
+
+// Verification outcomes
+const (
+	StatusUnchanged = "unchanged"
+	StatusModified  = "modified"
+	StatusMissing   = "missing"
+)
+
+// IsFile reports whether path names an existing regular file
+func IsFile(path string) bool {
+	if path == "" {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// Compute hashes the file at path and returns its checksum for one field of name
+func Compute(name, field, path string) (*model.Checksum, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return &model.Checksum{
+		Name:      name,
+		Field:     field,
+		Path:      path,
+		Size:      fi.Size(),
+		ModTime:   fi.ModTime(),
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		CheckedAt: time.Now(),
+	}, nil
+}
+
+// Verify re-hashes the file of a stored checksum and compares it
+// The current checksum is returned unless the file is missing
+func Verify(stored model.Checksum) (string, *model.Checksum, error) {
+	current, err := Compute(stored.Name, stored.Field, stored.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return StatusMissing, nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if current.Size != stored.Size || current.SHA256 != stored.SHA256 {
+		return StatusModified, current, nil
+	}
+	return StatusUnchanged, current, nil
+}
+
+// Refresh returns the current checksum of the file behind stored and whether its content changed
+// A file with the stored size and modification time is assumed unchanged without re-hashing it
+func Refresh(stored model.Checksum) (*model.Checksum, bool, error) {
+	fi, err := os.Stat(stored.Path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stat %s: %w", stored.Path, err)
+	}
+	if fi.Size() == stored.Size && fi.ModTime().Equal(stored.ModTime) {
+		return &stored, false, nil
+	}
+	current, err := Compute(stored.Name, stored.Field, stored.Path)
+	if err != nil {
+		return nil, false, err
+	}
+	return current, current.SHA256 != stored.SHA256, nil
+}
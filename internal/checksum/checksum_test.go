@@ -0,0 +1,62 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestComputeAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Compute("db:t", "path", path)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if c.Size != 8 || len(c.SHA256) != 64 {
+		t.Errorf("Unexpected checksum: %+v", c)
+	}
+
+	if status, _, err := Verify(*c); err != nil || status != StatusUnchanged {
+		t.Errorf("Expected unchanged, got %s (%v)", status, err)
+	}
+
+	// Same size, different content
+	if err := os.WriteFile(path, []byte("a,b\n1,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if status, current, _ := Verify(*c); status != StatusModified || current.SHA256 == c.SHA256 {
+		t.Errorf("Expected modified, got %s", status)
+	}
+
+	os.Remove(path)
+	if status, _, err := Verify(*c); err != nil || status != StatusMissing {
+		t.Errorf("Expected missing, got %s (%v)", status, err)
+	}
+	if IsFile(path) || IsFile(filepath.Dir(path)) {
+		t.Error("IsFile should reject missing files and directories")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	os.WriteFile(path, []byte("a"), 0644)
+	c, _ := Compute("db:t:r", "source", path)
+
+	// A touched file with the same content is not a change
+	later := c.ModTime.Add(time.Minute)
+	os.Chtimes(path, later, later)
+	current, changed, err := Refresh(*c)
+	if err != nil || changed || !current.ModTime.Equal(later) {
+		t.Errorf("Touched file: changed=%v current=%+v err=%v", changed, current, err)
+	}
+
+	os.WriteFile(path, []byte("b"), 0644)
+	if _, changed, _ := Refresh(*current); !changed {
+		t.Error("Rewritten file should be reported as changed")
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"srdm/internal/codebook"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	codebookFormat        string
+	codebookOutput        string
+	codebookTemplate      string
+	codebookTitle         string
+	codebookPrintTemplate bool
+)
+
+// codebookCmd represents the codebook command
+var codebookCmd = &cobra.Command{
+	Use:   "codebook [table]",
+	Short: "Render the data dictionary of a table",
+	Long: `Render a codebook listing every variable (record) of a table with its type,
+label, description, N, missing and distinct counts.
+
+Formats: ` + strings.Join(codebook.Formats(), ", ") + `. The format is detected from the
+output file extension (.md, .html, .tex, .doc) by default, otherwise Markdown is written.
+docx-compatible-html is HTML that Word opens as a document; save it as .doc.
+
+--template renders a Go text/template file instead. Start from a built-in one with
+--print-template; templates get .Title, .Table, .Variables and .Generated and the
+helpers md, html, latex, pct, note, attrs, date and join.`,
+	Example: `  srdm codebook proj:survey -o survey-codebook.html
+  srdm codebook proj:survey --format latex > codebook.tex
+  srdm codebook --print-template markdown > my.tmpl
+  srdm codebook proj:survey --template my.tmpl -o codebook.md`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if codebookPrintTemplate {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := codebookFormat
+		if format == "" {
+			if format = codebook.FormatForFile(codebookOutput); format == "" {
+				format = "markdown"
+			}
+		}
+		if codebookPrintTemplate {
+			text, err := codebook.Source(format)
+			if err != nil {
+				return err
+			}
+			fmt.Print(text)
+			return nil
+		}
+
+		var tmpl *template.Template
+		var err error
+		if codebookTemplate != "" {
+			text, err := os.ReadFile(codebookTemplate)
+			if err != nil {
+				return fmt.Errorf("failed to read template: %w", err)
+			}
+			tmpl, err = codebook.Parse(codebookTemplate, string(text))
+			if err != nil {
+				return err
+			}
+		} else if tmpl, err = codebook.Builtin(format); err != nil {
+			return err
+		}
+
+		t, err := Store.GetTable(args[0])
+		if err != nil {
+			return err
+		}
+
+		// Render in memory so a failing template leaves no partial file behind
+		var buf bytes.Buffer
+		if err := codebook.Render(&buf, tmpl, codebook.New(codebookTitle, t)); err != nil {
+			return err
+		}
+		if codebookOutput == "" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if err := os.WriteFile(codebookOutput, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		fmt.Printf("Wrote codebook of %s (%d variables) to %s\n", t.FullName(), len(t.Records), codebookOutput)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(codebookCmd)
+	codebookCmd.Flags().StringVar(&codebookFormat, "format", "", "Output format ("+strings.Join(codebook.Formats(), ", ")+"); detected from the output file extension by default")
+	codebookCmd.Flags().StringVarP(&codebookOutput, "output", "o", "", "Output file (default: stdout)")
+	codebookCmd.Flags().StringVar(&codebookTemplate, "template", "", "Render this Go text/template file instead of a built-in format")
+	codebookCmd.Flags().StringVar(&codebookTitle, "title", "", "Document title (default: Codebook: TABLE)")
+	codebookCmd.Flags().BoolVar(&codebookPrintTemplate, "print-template", false, "Print the built-in template of --format and exit")
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"srdm/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestCodebook(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		codebookFormat, codebookOutput, codebookTemplate, codebookTitle = "", "", "", ""
+		codebookPrintTemplate = false
+	}()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t", Records: []model.Record{
+		{Database: "db", Table: "t", Name: "age", Type: "int", Number: 10, MissNumber: 1},
+	}})
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "codebook.tex")
+	rootCmd.SetArgs([]string{"codebook", "db:t", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("codebook failed: %v", err)
+	}
+	content, _ := os.ReadFile(out)
+	if !strings.Contains(string(content), `\texttt{age} & int`) {
+		t.Errorf("Expected a LaTeX codebook for a .tex file:\n%s", content)
+	}
+
+	tmpl := filepath.Join(dir, "custom.tmpl")
+	os.WriteFile(tmpl, []byte("{{range .Variables}}{{.Name}}:{{.Type}}{{end}}"), 0644)
+	out = filepath.Join(dir, "custom.txt")
+	rootCmd.SetArgs([]string{"codebook", "db:t", "--template", tmpl, "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("codebook with template failed: %v", err)
+	}
+	if content, _ := os.ReadFile(out); string(content) != "age:int" {
+		t.Errorf("Custom template output = %q", content)
+	}
+
+	codebookTemplate, codebookOutput = "", ""
+	rootCmd.SetArgs([]string{"codebook", "db:missing"})
+	if err := rootCmd.Execute(); exitCode(err) != ExitNotFound {
+		t.Errorf("Expected not found for a missing table, got %v", err)
+	}
+	rootCmd.SetArgs([]string{"codebook", "--print-template", "--format", "html"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("print-template failed: %v", err)
+	}
+}
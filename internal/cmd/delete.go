@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"srdm/internal/store"
 
 	"github.com/spf13/cobra"
 )
@@ -14,14 +15,24 @@ var (
 var deleteCmd = &cobra.Command{
 	Use:   "delete [names]",
 	Short: "Delete data record or table",
-	Long:  `Delete data record or table by name. To delete a table, use --force option.`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Delete data record or table by name. To delete a table that still has records, use --force option.
+Deleted items are moved to the trash; bring them back with 'srdm restore' or remove them
+for good with 'srdm trash purge'.`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		for _, name := range args {
-			if err := Store.Delete(name, deleteForce); err != nil {
-				return fmt.Errorf("failed to delete %s: %w", name, err)
+		// Delete all names or none of them
+		if err := Store.WithTx(func(repo store.Repository) error {
+			for _, name := range args {
+				if err := repo.Delete(name, deleteForce); err != nil {
+					return fmt.Errorf("failed to delete %s: %w", name, err)
+				}
 			}
-			fmt.Printf("Deleted: %s\n", name)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, name := range args {
+			fmt.Printf("Moved to trash: %s\n", name)
 		}
 		return nil
 	},
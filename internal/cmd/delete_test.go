@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestDeleteAllOrNothing(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() { Store = nil }()
+
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a"})
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "u"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "u", Name: "b"})
+
+	// The table holds a record and needs --force, so db:t:a must not be deleted either
+	rootCmd.SetArgs([]string{"delete", "db:t:a", "db:u"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("Expected error deleting a table without --force")
+	}
+	if _, ok := mockStore.Records["db:t:a"]; !ok {
+		t.Error("Record deleted although the command failed")
+	}
+}
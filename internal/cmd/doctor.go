@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Ways doctor can fix orphan records
+const (
+	fixCreate = "create"
+	fixDelete = "delete"
+)
+
+var doctorFix string
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find and fix records without a parent table",
+	Long: `Check the repository for orphan records: records whose db:table does not exist.
+New repositories reject them, but older ones may hold records inserted under a
+mistyped or deleted table name.
+
+Without --fix the orphans are listed and the exit code is non-zero if any exist.
+--fix create adds an empty table for each missing parent, keeping the records.
+--fix delete removes the orphan records.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if doctorFix != "" && doctorFix != fixCreate && doctorFix != fixDelete {
+			return fmt.Errorf("invalid --fix %q (use create or delete)", doctorFix)
+		}
+
+		orphans, err := Store.Orphans()
+		if err != nil {
+			return err
+		}
+		if len(orphans) == 0 {
+			fmt.Println("No problems found.")
+			return nil
+		}
+
+		// Group the orphans by their missing table, keeping name order
+		var parents []string
+		children := map[string][]model.Record{}
+		for _, r := range orphans {
+			parent := r.Database + ":" + r.Table
+			if _, seen := children[parent]; !seen {
+				parents = append(parents, parent)
+			}
+			children[parent] = append(children[parent], r)
+		}
+		for _, parent := range parents {
+			fmt.Println(Colorize(Yellow, fmt.Sprintf("Missing table %s (%d orphan records)", parent, len(children[parent]))))
+			for _, r := range children[parent] {
+				fmt.Printf("  %s\n", r.FullName())
+			}
+		}
+
+		if doctorFix == "" {
+			return fmt.Errorf("%d orphan records in %d missing tables (fix with --fix create or --fix delete)", len(orphans), len(parents))
+		}
+		if err := Store.WithTx(func(repo store.Repository) error {
+			return fixOrphans(repo, parents, children)
+		}); err != nil {
+			return err
+		}
+		for _, parent := range parents {
+			if doctorFix == fixCreate {
+				fmt.Printf("Created table %s for %d records\n", parent, len(children[parent]))
+			} else {
+				fmt.Printf("Deleted %d orphan records of %s\n", len(children[parent]), parent)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVar(&doctorFix, "fix", "", "Fix orphan records: create their tables or delete them (create, delete)")
+}
+
+// fixOrphans creates the missing parent tables or deletes their records
+func fixOrphans(repo store.Repository, parents []string, children map[string][]model.Record) error {
+	now := time.Now()
+	for _, parent := range parents {
+		if doctorFix == fixDelete {
+			for _, r := range children[parent] {
+				if err := repo.Delete(r.FullName(), false); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		r := children[parent][0]
+		if err := repo.InsertTable(&model.Table{
+			Database:    r.Database,
+			Name:        r.Table,
+			Engine:      "SQLite3",
+			Description: "Created by srdm doctor for orphan records",
+			CreateAt:    now,
+			ModifyAt:    now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
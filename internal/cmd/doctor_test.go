@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		doctorFix = ""
+	}()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "ok"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "typo", Name: "a"})
+	mockStore.InsertRecord(&model.Record{Database: "old", Table: "gone", Name: "b"})
+
+	rootCmd.SetArgs([]string{"doctor"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected doctor to fail while orphans exist")
+	}
+
+	rootCmd.SetArgs([]string{"doctor", "--fix", "create"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("doctor --fix create failed: %v", err)
+	}
+	if _, ok := mockStore.Tables["db:typo"]; !ok {
+		t.Error("Missing parent table was not created")
+	}
+
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "lost", Name: "c"})
+	rootCmd.SetArgs([]string{"doctor", "--fix", "delete"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("doctor --fix delete failed: %v", err)
+	}
+	if _, ok := mockStore.Records["db:lost:c"]; ok {
+		t.Error("Orphan record was not deleted")
+	}
+	if len(mockStore.Records) != 3 {
+		t.Errorf("Expected 3 records left, got %d", len(mockStore.Records))
+	}
+
+	doctorFix = ""
+	rootCmd.SetArgs([]string{"doctor"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Expected a clean repository, got %v", err)
+	}
+}
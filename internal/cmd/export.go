@@ -1,55 +1,111 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"srdm/internal/export"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-var exportOutput string
+var (
+	exportOutput string
+	exportFormat string
+	exportTitle  string
+)
 
 var exportCmd = &cobra.Command{
-	Use:   "export [pattern]",
-	Short: "Export metadata of data records",
-	Long:  `Export metadata of matching data records to a JSON file.`,
-	Args:  cobra.MaximumNArgs(1),
+	Use:   "export [pattern] [output]",
+	Short: "Export metadata of tables and records",
+	Long: `Export the tables and records whose full name matches a LIKE pattern (default: all).
+Tables are exported with their records; records matching the pattern whose table
+does not are exported on their own.
+
+Formats: ` + strings.Join(export.Formats(), ", ") + `. The format is detected from the
+output file extension by default, otherwise JSON is written. The json, jsonl, csv
+and yaml outputs can be read back with 'srdm import'; markdown and html write a
+catalogue document, html as a single self-contained page. datapackage writes a
+Frictionless datapackage.json describing the selected tables, which 'srdm import'
+reads as well.
+
+rocrate writes the ro-crate-metadata.json of an RO-Crate and datacite a DataCite
+XML record, for depositing data at a repository. Dataset fields that tables do not
+hold are read from table attributes: title, description, creator (separated by ";",
+each optionally followed by an ORCID in parentheses), license, doi, publisher,
+published and keywords. Missing required fields are listed and nothing is written.`,
+	Example: `  srdm export 'proj:%' catalogue.html
+  srdm export 'proj:survey:%' --format csv -o survey.csv
+  srdm export proj:survey datapackage.json
+  srdm export proj:survey --format datacite -o datacite.xml`,
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		pattern := "%"
 		if len(args) > 0 {
 			pattern = args[0]
 		}
+		output := exportOutput
+		if len(args) == 2 {
+			if output != "" {
+				return fmt.Errorf("use either an output argument or --output, not both")
+			}
+			output = args[1]
+		}
 
-		records, err := Store.SearchRecords(pattern)
+		format := exportFormat
+		if format == "" {
+			if format = export.FormatForFile(output); format == "" {
+				format = "json"
+			}
+		}
+		exporter, err := export.Lookup(format)
 		if err != nil {
 			return err
 		}
 
-		if len(records) == 0 {
-			fmt.Fprintln(os.Stderr, "No records found to export.")
+		catalog, err := exportCatalog(pattern)
+		if err != nil {
+			return err
+		}
+		if len(catalog.Tables) == 0 && len(catalog.Records) == 0 {
+			fmt.Fprintln(os.Stderr, "Nothing found to export.")
 			return nil
 		}
-
-		var writer = os.Stdout
-		if exportOutput != "" {
-			file, err := os.Create(exportOutput)
-			if err != nil {
-				return fmt.Errorf("failed to create output file: %w", err)
+		catalog.Title = exportTitle
+		if output != "" {
+			if catalog.Dir, err = filepath.Abs(filepath.Dir(output)); err != nil {
+				return fmt.Errorf("failed to resolve %s: %w", output, err)
 			}
-			defer file.Close()
-			writer = file
 		}
 
-		enc := json.NewEncoder(writer)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(records); err != nil {
-			return fmt.Errorf("failed to encode records: %w", err)
+		if v, ok := exporter.(export.Validator); ok {
+			if problems := v.Validate(catalog); len(problems) > 0 {
+				if err := printProblems(problems); err != nil {
+					return err
+				}
+				return fmt.Errorf("%w: %d required fields missing for %s", export.ErrIncomplete, len(problems), format)
+			}
 		}
 
-		if exportOutput != "" {
-			fmt.Printf("Exported %d records to %s\n", len(records), exportOutput)
+		// Render in memory so a failed export leaves no partial file behind
+		var buf bytes.Buffer
+		if err := exporter.Export(&buf, catalog); err != nil {
+			return err
+		}
+		if output == "" {
+			_, err := os.Stdout.Write(buf.Bytes())
+			return err
+		}
+		if err := os.WriteFile(output, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
 		}
+		fmt.Printf("Exported %d tables and %d records to %s\n", len(catalog.Tables), catalog.RecordCount(), output)
 		return nil
 	},
 }
@@ -57,4 +113,48 @@ var exportCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(exportCmd)
 	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: stdout)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "Output format ("+strings.Join(export.Formats(), ", ")+"); detected from the output file extension by default")
+	exportCmd.Flags().StringVar(&exportTitle, "title", "", "Title of markdown and html catalogues (default: "+export.DefaultTitle+")")
+}
+
+// exportCatalog selects the tables and records matching a pattern
+// Records of selected tables are nested in them rather than listed again
+func exportCatalog(pattern string) (*export.Catalog, error) {
+	tables, err := Store.FilterTables(store.Query{Where: "data_table.name LIKE ?", Args: []any{pattern}})
+	if err != nil {
+		return nil, err
+	}
+	records, err := Store.SearchRecords(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		selected[t.FullName()] = true
+	}
+	var standalone []model.Record
+	for _, r := range records {
+		if !selected[r.Database+":"+r.Table] {
+			standalone = append(standalone, r)
+		}
+	}
+	return &export.Catalog{Generated: time.Now(), Tables: tables, Records: standalone}, nil
+}
+
+// printProblems lists the fields an export format is missing
+func printProblems(problems []export.Problem) error {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tFIELD\tPROBLEM")
+	for _, p := range problems {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Name, p.Field, p.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	header, rows, _ := strings.Cut(buf.String(), "\n")
+	fmt.Fprintln(os.Stderr, Colorize(Cyan, header))
+	fmt.Fprint(os.Stderr, rows)
+	return nil
 }
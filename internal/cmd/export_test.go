@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"srdm/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestExportFormatsRoundTrip(t *testing.T) {
+	defer func() {
+		Store = nil
+		exportOutput, exportFormat, exportTitle = "", "", ""
+		importFormat, importOnConflict, importDryRun = "", "fail", false
+	}()
+	source := NewMockRepository()
+	rec := model.Record{Database: "db", Table: "t", Name: "a", Type: "int", Number: 10,
+		Tags: []string{"raw"}, Attributes: map[string]string{"unit": "kg"}}
+	source.InsertTable(&model.Table{Database: "db", Name: "t", Keys: "id", Records: []model.Record{rec}})
+	source.InsertRecord(&rec)
+
+	dir := t.TempDir()
+	for _, ext := range []string{"json", "jsonl", "csv", "yaml"} {
+		Store = source
+		path := filepath.Join(dir, "out."+ext)
+		rootCmd.SetArgs([]string{"export", "db:%", path})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("export %s failed: %v", ext, err)
+		}
+
+		target := NewMockRepository()
+		Store = target
+		rootCmd.SetArgs([]string{"import", path})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("import %s failed: %v", ext, err)
+		}
+		got := target.Records["db:t:a"]
+		if target.Tables["db:t"] == nil || got == nil || got.Number != 10 || got.Attributes["unit"] != "kg" || len(got.Tags) != 1 {
+			t.Errorf("%s round trip lost data: %+v", ext, got)
+		}
+	}
+
+	Store = source
+	path := filepath.Join(dir, "catalogue.txt")
+	rootCmd.SetArgs([]string{"export", "db:%", path, "--format", "html", "--title", "Test <Catalogue>"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("export html failed: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "<title>Test &lt;Catalogue&gt;</title>") {
+		t.Error("HTML title not set or not escaped")
+	}
+
+	// Archive formats list missing required fields and write nothing
+	path = filepath.Join(dir, "datacite.xml")
+	exportFormat, exportTitle = "", ""
+	rootCmd.SetArgs([]string{"export", "db:t", path})
+	if err := rootCmd.Execute(); exitCode(err) != ExitIncomplete {
+		t.Errorf("Expected exit code %d for incomplete metadata, got %v", ExitIncomplete, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Incomplete export left a file behind")
+	}
+
+	rootCmd.SetArgs([]string{"export", "db:%", "--format", "pdf"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected unsupported format error")
+	}
+}
@@ -1,76 +1,306 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"srdm/internal/checksum"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-var getOutput string
+var (
+	getOutput string
+	getWhat   string
+)
+
+// Files that can be extracted with --what
+const (
+	getData   = "data"
+	getSource = "source"
+	getScript = "script"
+	getDesc   = "desc"
+	getLog    = "log"
+	getAll    = "all"
+)
+
+// getFields lists the extractable files in the order --what all copies them
+var getFields = []string{getData, getSource, getScript, getDesc, getLog}
 
 var getCmd = &cobra.Command{
-	Use:   "get [name]",
+	Use:   "get [name|glob]",
 	Short: "Extract data file",
-	Long:  `Extract original file associated with data record to local.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Extract original files associated with a table or record to local.
+
+By default a table's data file (path) or a record's source file is copied.
+--what picks another file: data, source, script, desc, log, or all of them.
+
+A glob pattern such as 'survey:*' or 'survey:wave1:*.csv' extracts every matching
+table and record into a directory tree mirroring db/table/record below --output
+(default: the current directory). So does --what all.
+
+Each copy is re-read and checked against the source, and against the checksum
+recorded at registration when there is one. Files that fail are reported and make
+the command exit with an error.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
+		if getWhat != "" && getWhat != getAll && !slices.Contains(getFields, getWhat) {
+			return fmt.Errorf("invalid --what %q (use data, source, script, desc, log or all)", getWhat)
+		}
 
-		// For now, only support Table extraction (DB file)
-		// Assuming Record doesn't strictly have a file path stored directly in struct except logs/scripts
-		// But let's assume if it's Table, we copy the .Path
+		items, err := getItems(name)
+		if err != nil {
+			return err
+		}
 
-		t, err := Store.GetTable(name)
-		if err == nil && t != nil {
-			src := t.Path
+		// A single file keeps the original behaviour: copy it to --output or its base name
+		if !isGlob(name) && getWhat != getAll {
+			f := items[0].file(getFieldName(items[0]))
+			if f.path == "" {
+				return fmt.Errorf("%s has no %s file", name, f.field)
+			}
 			dst := getOutput
 			if dst == "" {
-				dst = filepath.Base(src)
+				dst = filepath.Base(f.path)
+			}
+			if err := extractFile(f, dst); err != nil {
+				return err
 			}
-			return copyFile(src, dst)
+			fmt.Printf("Extracted to %s\n", dst)
+			return nil
 		}
 
-		// TODO: Implement Logic for Record if Record has a "Path" or associated file.
-		// Current Model for Record doesn't have a "DataPath", only Script/Desc/Log files.
-		// So we return error for now or implement copying one of those if asked.
-
-		return fmt.Errorf("resource not found or extraction not supported for this type: %s", name)
+		files := getFiles(items)
+		if len(files) == 0 {
+			return fmt.Errorf("no files to extract for %s", name)
+		}
+		root := getOutput
+		if root == "" {
+			root = "."
+		}
+		failed := 0
+		for _, f := range files {
+			dst := filepath.Join(root, f.dir, filepath.Base(f.path))
+			if err := extractFile(f, dst); err != nil {
+				fmt.Fprintf(os.Stderr, "%s (%s): %v\n", f.name, f.field, err)
+				failed++
+				continue
+			}
+			fmt.Printf("Extracted to %s\n", dst)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d files failed", failed, len(files))
+		}
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(getCmd)
-	getCmd.Flags().StringVarP(&getOutput, "output", "o", "", "Output filename (default: original filename)")
+	getCmd.Flags().StringVarP(&getOutput, "output", "o", "", "Output filename, or directory for globs and --what all (default: original filename)")
+	getCmd.Flags().StringVar(&getWhat, "what", "", "File to extract: data, source, script, desc, log or all (default: data for tables, source for records)")
 }
 
-func copyFile(src, dst string) error {
-	sourceFileStat, err := os.Stat(src)
+// getItem is a table or record selected for extraction
+type getItem struct {
+	table  *model.Table
+	record *model.Record
+}
+
+// getFile is one file to extract
+type getFile struct {
+	name  string // Full name of the owning table or record
+	field string // Extracted field, e.g. data or script
+	path  string // Path of the original file
+	dir   string // Directory of the copy in a tree: db/table or db/table/record
+	sum   string // Checksum field recorded at registration, if any
+}
+
+// getItems resolves an exact name or a glob pattern to tables and records
+func getItems(name string) ([]getItem, error) {
+	if !isGlob(name) {
+		t, err := Store.GetTable(name)
+		if err == nil {
+			return []getItem{{table: t}}, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+		r, err := Store.GetRecord(name)
+		if err != nil {
+			return nil, err
+		}
+		return []getItem{{record: r}}, nil
+	}
+
+	tables, err := Store.FilterTables(store.Query{Where: "data_table.name GLOB ?", Args: []any{name}})
+	if err != nil {
+		return nil, err
+	}
+	records, err := Store.FilterRecords(store.Query{Where: "data_record.name GLOB ?", Args: []any{name}})
+	if err != nil {
+		return nil, err
+	}
+	var items []getItem
+	for i := range tables {
+		items = append(items, getItem{table: &tables[i]})
+	}
+	for i := range records {
+		items = append(items, getItem{record: &records[i]})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no tables or records match %s", name)
+	}
+	return items, nil
+}
+
+// getFiles lists the files selected by --what for each item
+// Fields that are empty or do not name a file are skipped, since sources may be free text
+func getFiles(items []getItem) []getFile {
+	var files []getFile
+	for _, it := range items {
+		fields := []string{getWhat}
+		if getWhat == getAll {
+			fields = getFields
+		} else if getWhat == "" {
+			fields = []string{getFieldName(it)}
+		}
+		for _, field := range fields {
+			f := it.file(field)
+			if f.path != "" && checksum.IsFile(f.path) {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// getFieldName is the default field extracted from an item
+func getFieldName(it getItem) string {
+	if getWhat != "" && getWhat != getAll {
+		return getWhat
+	}
+	if it.table != nil {
+		return getData
+	}
+	return getSource
+}
+
+// file returns one field's file of the item
+func (it getItem) file(field string) getFile {
+	if it.table != nil {
+		t := it.table
+		f := getFile{name: t.FullName(), field: field, dir: filepath.Join(t.Database, t.Name)}
+		switch field {
+		case getData:
+			f.path, f.sum = t.Path, model.FileFieldPath
+		case getSource:
+			f.path = t.Source
+		case getScript:
+			f.path = t.ScriptFile
+		case getDesc:
+			f.path = t.DescFile
+		case getLog:
+			f.path = t.LogFile
+		}
+		return f
+	}
+
+	r := it.record
+	f := getFile{name: r.FullName(), field: field, dir: filepath.Join(r.Database, r.Table, filepath.FromSlash(r.Name))}
+	switch field {
+	case getSource:
+		f.path, f.sum = r.Source, model.FileFieldSource
+	case getScript:
+		f.path = r.ScriptFile
+	case getDesc:
+		f.path = r.DescFile
+	case getLog:
+		f.path = r.LogFile
+	}
+	return f
+}
+
+// extractFile copies f to dst and verifies the copy
+// The copy must match the bytes read from the source and, when the file was
+// registered with a checksum, the recorded SHA-256
+func extractFile(f getFile, dst string) error {
+	if dir := filepath.Dir(dst); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	copied, err := copyFile(f.path, dst)
+	if err != nil {
+		return err
+	}
+	written, err := checksum.Compute(f.name, f.field, dst)
+	if err != nil {
+		return err
+	}
+	if written.SHA256 != copied {
+		return fmt.Errorf("copy of %s is corrupt: checksum mismatch", f.path)
+	}
+	if f.sum == "" {
+		return nil
+	}
+
+	c, err := Store.Checksum(f.name, f.sum)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	if c.Path == f.path && c.SHA256 != copied {
+		return fmt.Errorf("%s changed since it was registered (run 'srdm verify %s')", f.path, f.name)
+	}
+	return nil
+}
+
+// copyFile copies src to dst and returns the hex SHA-256 of the copied bytes
+func copyFile(src, dst string) (string, error) {
+	sourceFileStat, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
 
 	if !sourceFileStat.Mode().IsRegular() {
-		return fmt.Errorf("%s is not a regular file", src)
+		return "", fmt.Errorf("%s is not a regular file", src)
 	}
 
 	source, err := os.Open(src)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer source.Close()
 
 	destination, err := os.Create(dst)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer destination.Close()
 
-	if _, err := io.Copy(destination, source); err != nil {
-		return err
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(destination, h), source); err != nil {
+		return "", err
 	}
-	fmt.Printf("Extracted to %s\n", dst)
-	return nil
+	if err := destination.Close(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isGlob reports whether name contains glob metacharacters
+func isGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
 }
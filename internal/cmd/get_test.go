@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"srdm/internal/checksum"
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestGetRecordFiles(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		getOutput, getWhat = "", ""
+	}()
+
+	src := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(src, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	data := write("wave1.csv", "a,b\n1,2\n")
+	script := write("clean.R", "print(1)")
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "wave1", Source: data, ScriptFile: script})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "notes", Source: "World Bank"})
+	c, _ := checksum.Compute("db:t:wave1", model.FileFieldSource, data)
+	mockStore.SaveChecksum(c)
+
+	// A single record copies its source to --output
+	out := t.TempDir()
+	rootCmd.SetArgs([]string{"get", "db:t:wave1", "-o", filepath.Join(out, "copy.csv")})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(out, "copy.csv")); string(b) != "a,b\n1,2\n" {
+		t.Errorf("Unexpected copy: %q", b)
+	}
+
+	rootCmd.SetArgs([]string{"get", "db:t:wave1", "--what", "log"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for a record without a log file")
+	}
+
+	// A glob extracts every file into db/table/record, skipping free-text sources
+	rootCmd.SetArgs([]string{"get", "db:t:*", "--what", "all", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Get glob failed: %v", err)
+	}
+	for _, name := range []string{"wave1.csv", "clean.R"} {
+		if _, err := os.Stat(filepath.Join(out, "db", "t", "wave1", name)); err != nil {
+			t.Errorf("Missing extracted file %s: %v", name, err)
+		}
+	}
+
+	// A source that changed since registration fails verification
+	write("wave1.csv", "a,b\n1,3\n")
+	getWhat = ""
+	rootCmd.SetArgs([]string{"get", "db:t:*", "-o", out})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected checksum verification error")
+	}
+
+	getOutput = ""
+	rootCmd.SetArgs([]string{"get", "db:t:missing"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for a missing record")
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyFormat string
+	revertTo      int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history [name]",
+	Short: "Show the change history of a table or record",
+	Long: `Show every insert, update and delete of a table or record,
+with the OS user, the time and the fields that changed.
+Use --format json to see the full before and after states.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := Store.History(args[0])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Fprintf(os.Stderr, "no history: %s\n", args[0])
+			return nil
+		}
+
+		if historyFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+
+		// Align without color codes, which would count towards the column widths
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tACTION\tUSER\tCHANGED AT\tFIELDS")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+				e.Version, e.Action, e.User,
+				e.ChangedAt.Format(time.RFC3339),
+				strings.Join(changedFields(e.Before, e.After), ", "),
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		header, rows, _ := strings.Cut(buf.String(), "\n")
+		fmt.Println(Colorize(Cyan, header))
+		fmt.Print(rows)
+		return nil
+	},
+}
+
+var revertCmd = &cobra.Command{
+	Use:   "revert [name]",
+	Short: "Restore a table or record to an earlier version",
+	Long: `Restore a table or record to the state it had right after the given history version.
+A deleted item is re-created. The restore is itself recorded in the history.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if revertTo <= 0 {
+			return fmt.Errorf("--to is required")
+		}
+		if err := Store.Revert(args[0], revertTo); err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %s to version %d\n", args[0], revertTo)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(revertCmd)
+
+	historyCmd.Flags().StringVar(&historyFormat, "format", "text", "Output format (text, json)")
+	revertCmd.Flags().IntVar(&revertTo, "to", 0, "History version to restore")
+}
+
+// changedFields lists the top-level JSON fields that differ between two snapshots
+// The modification timestamp is ignored since it changes on every update
+func changedFields(before, after json.RawMessage) []string {
+	var b, a map[string]any
+	_ = json.Unmarshal(before, &b)
+	_ = json.Unmarshal(after, &a)
+
+	keys := make(map[string]bool)
+	for k := range b {
+		keys[k] = true
+	}
+	for k := range a {
+		keys[k] = true
+	}
+
+	var fields []string
+	for k := range keys {
+		if k == "modify_at" {
+			continue
+		}
+		if !reflect.DeepEqual(b[k], a[k]) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
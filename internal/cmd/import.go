@@ -0,0 +1,494 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"srdm/internal/datapackage"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	importFormat     string
+	importOnConflict string
+	importDryRun     bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Bulk import tables and records from JSON, CSV or YAML",
+	Long: `Import metadata of tables and records from a file, in one transaction.
+
+JSON and YAML files hold a list of items in the shape written by 'export';
+JSON lines files hold one item per line. A Frictionless datapackage.json becomes
+one table per resource, with the schema fields as records.
+An item with a "table" field (or a "name" of the form db:table:record) is a record,
+otherwise it is a table; a table's nested "records" are imported too.
+CSV and TSV files need a header row with the same field names; tags are separated by ";"
+and attr.KEY columns set the custom attribute KEY.
+
+If any row fails, nothing is written and every failing row is reported.
+Use '-' to read from stdin together with --format.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		policy, err := store.ParseConflictPolicy(importOnConflict)
+		if err != nil {
+			return err
+		}
+
+		format := importFormat
+		if format == "" {
+			if format = formatFromExt(args[0]); format == "" {
+				return fmt.Errorf("cannot detect the format of %s, use --format", args[0])
+			}
+		}
+
+		var in io.Reader = os.Stdin
+		if args[0] != "-" {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open import file: %w", err)
+			}
+			defer file.Close()
+			in = file
+		}
+
+		rows, err := readImportRows(in, format, args[0])
+		if err != nil {
+			return err
+		}
+
+		items, report := importItems(rows)
+		// Rows that could not be parsed abort the import, but the rest is still
+		// checked against the repository so the report is complete
+		opts := store.ImportOptions{OnConflict: policy, DryRun: importDryRun || len(report) > 0}
+		var results []store.ImportResult
+		if opts.DryRun {
+			results, err = Store.Import(items, opts)
+		} else {
+			// Checksums are recorded in the same transaction, so a file that cannot
+			// be hashed leaves nothing imported
+			err = Store.WithTx(func(repo store.Repository) error {
+				var err error
+				if results, err = repo.Import(items, opts); err != nil {
+					return err
+				}
+				return trackImportedFiles(repo, items, results)
+			})
+		}
+		if err != nil && !errors.Is(err, store.ErrImportFailed) {
+			return err
+		}
+
+		counts := map[string]int{}
+		for _, res := range results {
+			counts[res.Action]++
+			if res.Err != nil {
+				report = append(report, res)
+			}
+		}
+		sort.SliceStable(report, func(i, j int) bool { return report[i].Row < report[j].Row })
+		failedRows := map[int]bool{}
+		for _, res := range report {
+			failedRows[res.Row] = true
+			if res.Name != "" {
+				fmt.Fprintf(os.Stderr, "row %d (%s): %v\n", res.Row, res.Name, res.Err)
+			} else {
+				fmt.Fprintf(os.Stderr, "row %d: %v\n", res.Row, res.Err)
+			}
+		}
+
+		if len(report) > 0 {
+			return fmt.Errorf("%d of %d rows failed: %w", len(failedRows), len(rows), store.ErrImportFailed)
+		}
+
+		prefix := "Imported"
+		if importDryRun {
+			prefix = "Dry run, nothing written. Would import"
+		}
+		fmt.Printf("%s %d items: %d inserted, %d updated, %d skipped\n", prefix, len(results),
+			counts[store.ImportInserted], counts[store.ImportUpdated], counts[store.ImportSkipped])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().StringVar(&importFormat, "format", "", "Input format (json, jsonl, csv, tsv, yaml, datapackage); detected from the file extension by default")
+	importCmd.Flags().StringVar(&importOnConflict, "on-conflict", "fail", "What to do with items that already exist (skip, update, fail)")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Check the import and report what would change without writing")
+}
+
+// importRow is one item of an import file with its position
+type importRow struct {
+	Row    int
+	Fields map[string]any
+}
+
+// formatFromExt maps a file name or extension to an import format
+func formatFromExt(path string) string {
+	if strings.EqualFold(filepath.Base(path), "datapackage.json") {
+		return "datapackage"
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".csv":
+		return "csv"
+	case ".tsv", ".tab":
+		return "tsv"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
+
+// readImportRows decodes the import file into generic rows
+// path locates the files a data package refers to; it is "-" for stdin
+func readImportRows(in io.Reader, format, path string) ([]importRow, error) {
+	switch format {
+	case "json":
+		var data any
+		if err := json.NewDecoder(in).Decode(&data); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		return listRows(data)
+	case "jsonl":
+		return jsonlRows(in)
+	case "datapackage":
+		return dataPackageRows(in, path)
+	case "yaml":
+		var data any
+		if err := yaml.NewDecoder(in).Decode(&data); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		return listRows(data)
+	case "csv", "tsv":
+		r := csv.NewReader(in)
+		if format == "tsv" {
+			r.Comma = '\t'
+			r.LazyQuotes = true
+		}
+		return csvRows(r)
+	}
+	return nil, fmt.Errorf("unsupported format: %s (use json, jsonl, csv, tsv, yaml or datapackage)", format)
+}
+
+// listRows accepts a list of objects or a single object
+func listRows(data any) ([]importRow, error) {
+	var list []any
+	switch v := data.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		list = v
+	case map[string]any:
+		list = []any{v}
+	default:
+		return nil, fmt.Errorf("expected a list of tables or records")
+	}
+
+	rows := make([]importRow, len(list))
+	for i, item := range list {
+		rows[i].Row = i + 1
+		fields, ok := item.(map[string]any)
+		if !ok {
+			fields = nil
+		}
+		rows[i].Fields = fields
+	}
+	return rows, nil
+}
+
+// jsonlRows reads one object per line, as written by 'srdm export --format jsonl'
+// Rows are numbered by their line in the file; blank lines are skipped
+func jsonlRows(in io.Reader) ([]importRow, error) {
+	var rows []importRow
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(text), &fields); err != nil {
+			return nil, fmt.Errorf("line %d: failed to parse JSON: %w", line, err)
+		}
+		rows = append(rows, importRow{Row: line, Fields: fields})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+	return rows, nil
+}
+
+// dataPackageRows reads a Frictionless Data Package descriptor, one row per resource
+// Resources become tables of the database named after the package unless they
+// were exported by srdm; relative resource paths are resolved against the descriptor
+func dataPackageRows(in io.Reader, path string) ([]importRow, error) {
+	var p datapackage.Package
+	if err := json.NewDecoder(in).Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse data package: %w", err)
+	}
+	dir := ""
+	if path != "-" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+		dir = filepath.Dir(abs)
+	}
+	tables, err := p.Tables(p.Name, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]importRow, len(tables))
+	for i := range tables {
+		data, err := json.Marshal(&tables[i])
+		if err != nil {
+			return nil, err
+		}
+		rows[i].Row = i + 1
+		if err := json.Unmarshal(data, &rows[i].Fields); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// csvRows reads a header row followed by one item per line
+// Rows are numbered by their line in the file, the header being line 1
+func csvRows(r *csv.Reader) ([]importRow, error) {
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var rows []importRow
+	for line := 2; ; line++ {
+		values, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		fields := map[string]any{}
+		for i, v := range values {
+			if i < len(header) && header[i] != "" && strings.TrimSpace(v) != "" {
+				fields[header[i]] = strings.TrimSpace(v)
+			}
+		}
+		if len(fields) > 0 {
+			rows = append(rows, importRow{Row: line, Fields: fields})
+		}
+	}
+}
+
+// importItems converts rows into tables and records
+// Rows that cannot be converted are returned as failed results
+func importItems(rows []importRow) ([]store.ImportItem, []store.ImportResult) {
+	var items []store.ImportItem
+	var failed []store.ImportResult
+	for _, row := range rows {
+		converted, err := rowItems(row)
+		if err != nil {
+			failed = append(failed, store.ImportResult{Row: row.Row, Action: store.ImportFailed, Err: err})
+			continue
+		}
+		items = append(items, converted...)
+	}
+	return items, failed
+}
+
+// rowItems decodes one row into a table (plus its nested records) or a record
+func rowItems(row importRow) ([]store.ImportItem, error) {
+	if row.Fields == nil {
+		return nil, fmt.Errorf("expected an object")
+	}
+	if err := normalizeFields(row.Fields); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(row.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var r model.Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid fields: %w", err)
+	}
+	// A full name may be given instead of separate parts
+	if r.Database == "" && strings.Contains(r.Name, ":") {
+		parts := strings.Split(r.Name, ":")
+		switch len(parts) {
+		case 2:
+			r.Database, r.Name = parts[0], parts[1]
+		case 3:
+			r.Database, r.Table, r.Name = parts[0], parts[1], parts[2]
+		default:
+			return nil, fmt.Errorf("%w %q", store.ErrInvalidName, r.Name)
+		}
+	}
+	if r.Table != "" {
+		if _, nested := row.Fields["records"]; nested {
+			return nil, fmt.Errorf("record %s cannot have nested records", r.FullName())
+		}
+		return []store.ImportItem{{Row: row.Row, Record: &r}}, nil
+	}
+
+	var t model.Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid fields: %w", err)
+	}
+	t.Database, t.Name = r.Database, r.Name
+	if t.Engine == "" {
+		t.Engine = "SQLite3"
+	}
+
+	items := []store.ImportItem{{Row: row.Row, Table: &t}}
+	for i := range t.Records {
+		rec := &t.Records[i]
+		if rec.Database == "" {
+			rec.Database = t.Database
+		}
+		if rec.Table == "" {
+			rec.Table = t.Name
+		}
+		if rec.Database != t.Database || rec.Table != t.Name {
+			return nil, fmt.Errorf("nested record %s does not belong to %s", rec.FullName(), t.FullName())
+		}
+		items = append(items, store.ImportItem{Row: row.Row, Record: rec})
+	}
+	t.Records = nil
+	return items, nil
+}
+
+// importIntFields and importTimeFields are converted from text, as found in CSV files
+var (
+	importIntFields  = []string{"number", "missnumber", "uniquenumber"}
+	importTimeFields = []string{"create_at", "modify_at"}
+)
+
+// normalizeFields converts text values of numeric, time and tag fields
+// so the row decodes into the model types
+// attr.KEY columns, as found in CSV files, are collected into attributes
+// and attribute values are converted to text
+func normalizeFields(fields map[string]any) error {
+	attrs := map[string]string{}
+	for key, value := range fields {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "attr.") && len(key) > len("attr.") {
+			if value != nil && value != "" {
+				attrs[key[len("attr."):]] = fmt.Sprint(value)
+			}
+			delete(fields, key)
+			continue
+		}
+		// YAML and JSON may hold numbers or booleans as attribute values
+		if nested, ok := value.(map[string]any); ok && lower == "attributes" {
+			for k, v := range nested {
+				if v != nil {
+					attrs[k] = fmt.Sprint(v)
+				}
+			}
+			delete(fields, key)
+			continue
+		}
+		if nested, ok := value.([]any); ok && lower == "records" {
+			for _, item := range nested {
+				if rec, ok := item.(map[string]any); ok {
+					if err := normalizeFields(rec); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		switch {
+		case lower == "tags":
+			fields[key] = splitTags(s)
+		case slices.Contains(importIntFields, lower):
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("%s must be an integer, got %q", key, s)
+			}
+			fields[key] = n
+		case slices.Contains(importTimeFields, lower):
+			t, err := parseImportTime(s)
+			if err != nil {
+				return fmt.Errorf("%s must be a date or RFC3339 time, got %q", key, s)
+			}
+			fields[key] = t
+		}
+	}
+	if len(attrs) > 0 {
+		fields["attributes"] = attrs
+	}
+	return nil
+}
+
+// splitTags splits a tag list separated by ";" or ","
+func splitTags(s string) []string {
+	return store.NormalizeTags(strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }))
+}
+
+// parseImportTime accepts RFC3339 timestamps, dates and local date-times
+func parseImportTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// trackImportedFiles records the checksums of the files of inserted and updated items
+func trackImportedFiles(repo store.Repository, items []store.ImportItem, results []store.ImportResult) error {
+	for i, it := range items {
+		if results[i].Action != store.ImportInserted && results[i].Action != store.ImportUpdated {
+			continue
+		}
+		var files map[string]string
+		if it.Table != nil {
+			files = tableFiles(it.Table)
+		} else {
+			files = recordFiles(it.Record)
+		}
+		if err := trackFiles(repo, it.Name(), files); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"srdm/internal/store"
+	"testing"
+)
+
+func TestImportCSV(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		importFormat, importOnConflict, importDryRun = "", "fail", false
+	}()
+
+	path := filepath.Join(t.TempDir(), "records.csv")
+	data := "name,type,label,number,tags,attr.unit\n" +
+		"db:t,,,,,\n" +
+		"db:t:a,int,first,10,raw;cleaned,kg\n" +
+		"db:t:b,string,second,20,,\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"import", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if _, ok := mockStore.Tables["db:t"]; !ok {
+		t.Error("Table db:t not imported")
+	}
+	rec := mockStore.Records["db:t:a"]
+	if rec == nil || rec.Number != 10 || rec.Label != "first" || len(rec.Tags) != 2 || rec.Attributes["unit"] != "kg" {
+		t.Errorf("Record db:t:a not imported correctly: %+v", rec)
+	}
+
+	// Importing again conflicts on every row
+	rootCmd.SetArgs([]string{"import", path})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected conflict error")
+	}
+	rootCmd.SetArgs([]string{"import", path, "--on-conflict", "skip"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Skip import failed: %v", err)
+	}
+}
+
+func TestImportCSVRowErrors(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		importFormat, importOnConflict, importDryRun = "", "fail", false
+	}()
+
+	path := filepath.Join(t.TempDir(), "records.csv")
+	data := "name,number\n" +
+		"db:t,\n" +
+		"db:t:b,x\n" +
+		"db:t:c,4\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"import", path})
+	err := rootCmd.Execute()
+	if !errors.Is(err, store.ErrImportFailed) {
+		t.Fatalf("Expected ErrImportFailed for invalid number, got %v", err)
+	}
+	if len(mockStore.Tables) != 0 || len(mockStore.Records) != 0 {
+		t.Error("Failed import should not write anything")
+	}
+}
+
+func TestImportYAMLRowErrors(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		importFormat, importOnConflict, importDryRun = "", "fail", false
+	}()
+
+	path := filepath.Join(t.TempDir(), "tables.yaml")
+	data := `
+- database: db
+  name: t
+  keys: id
+  records:
+    - name: a
+      number: 5
+- name: db:t:b
+  number: many
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"import", path})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("Expected error for invalid number")
+	}
+	if len(mockStore.Tables) != 0 || len(mockStore.Records) != 0 {
+		t.Error("Failed import should not write anything")
+	}
+
+	data = "- database: db\n  name: t\n  keys: id\n  records:\n    - name: a\n      number: 5\n"
+	os.WriteFile(path, []byte(data), 0644)
+	rootCmd.SetArgs([]string{"import", path, "--dry-run"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(mockStore.Tables) != 0 {
+		t.Error("Dry run should not write")
+	}
+
+	importDryRun = false
+	rootCmd.SetArgs([]string{"import", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if rec := mockStore.Records["db:t:a"]; rec == nil || rec.Number != 5 {
+		t.Errorf("Nested record not imported: %+v", rec)
+	}
+}
+
+func TestImportDataPackage(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		importFormat, importOnConflict, importDryRun = "", "fail", false
+	}()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "datapackage.json")
+	data := `{"name": "climate", "resources": [{"name": "temps", "path": "temps.csv",
+		"schema": {"fields": [{"name": "day", "type": "date"}, {"name": "tmax", "type": "integer"}], "primaryKey": ["day"]}}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"import", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	table := mockStore.Tables["climate:temps"]
+	if table == nil || table.Keys != "day" || table.Path != filepath.Join(dir, "temps.csv") {
+		t.Errorf("Table not imported correctly: %+v", table)
+	}
+	if rec := mockStore.Records["climate:temps:tmax"]; rec == nil || rec.Type != "int" {
+		t.Errorf("Field not imported as a record: %+v", rec)
+	}
+}
+
+func TestImportChecksumFailureRollsBack(t *testing.T) {
+	mockStore := NewMockRepository()
+	mockStore.ChecksumErr = errors.New("disk error")
+	Store = mockStore
+	defer func() {
+		Store = nil
+		importFormat, importOnConflict, importDryRun = "", "fail", false
+	}()
+
+	dir := t.TempDir()
+	dataFile := filepath.Join(dir, "t.csv")
+	if err := os.WriteFile(dataFile, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "items.jsonl")
+	if err := os.WriteFile(path, []byte(`{"name": "db:t", "path": "`+dataFile+`"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"import", path})
+	if err := rootCmd.Execute(); err == nil {
+		t.Fatal("Expected the checksum error")
+	}
+	if _, ok := mockStore.Tables["db:t"]; ok {
+		t.Error("Table imported although its checksum was not recorded")
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"srdm/internal/model"
-	"strings"
+	"srdm/internal/store"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -29,6 +29,8 @@ var (
 	insertNumber       int
 	insertMissNumber   int
 	insertUniqueNumber int
+	insertTags         []string
+	insertAttrs        []string
 )
 
 // insertCmd represents the insert command
@@ -44,14 +46,14 @@ var insertCmd = &cobra.Command{
 		// Parse name to decide if it is a Table or Record
 		// database:table => Table
 		// database:table:record => Record
-		parts := strings.Split(insertName, ":")
+		parts, err := store.SplitName(insertName)
+		if err != nil {
+			return err
+		}
 		if len(parts) == 2 {
 			return insertTable(parts[0], parts[1])
-		} else if len(parts) == 3 {
-			return insertRecord(parts[0], parts[1], parts[2])
-		} else {
-			return fmt.Errorf("invalid name format. Use 'db:table' for table or 'db:table:record' for record")
 		}
+		return insertRecord(parts[0], parts[1], parts[2])
 	},
 }
 
@@ -69,6 +71,8 @@ func init() {
 	insertCmd.Flags().StringVar(&insertDescFile, "desc_file", "", "Data analysis file")
 	insertCmd.Flags().StringVar(&insertDescTag, "desc_tag", "", "Analysis file version tag")
 	insertCmd.Flags().StringVar(&insertLogFile, "log_file", "", "Data usage log file")
+	insertCmd.Flags().StringSliceVar(&insertTags, "tag", nil, "Tag to attach (repeatable or comma-separated)")
+	insertCmd.Flags().StringArrayVar(&insertAttrs, "attr", nil, "Custom attribute as key=value (repeatable)")
 
 	// Record specific options
 	insertCmd.Flags().StringVar(&insertType, "type", "", "Record type")
@@ -82,6 +86,10 @@ func insertTable(database, name string) error {
 	if insertKeys == "" {
 		return fmt.Errorf("--keys is required for table")
 	}
+	attrs, err := mergeAttributes(nil, insertAttrs)
+	if err != nil {
+		return err
+	}
 
 	// Default path logic
 	dataPath := insertPath
@@ -104,11 +112,18 @@ func insertTable(database, name string) error {
 		DescFile:    insertDescFile,
 		DescTag:     insertDescTag,
 		LogFile:     insertLogFile,
+		Tags:        insertTags,
+		Attributes:  attrs,
 		CreateAt:    time.Now(),
 		ModifyAt:    time.Now(),
 	}
 
-	if err := Store.InsertTable(table); err != nil {
+	if err := Store.WithTx(func(repo store.Repository) error {
+		if err := repo.InsertTable(table); err != nil {
+			return err
+		}
+		return trackFiles(repo, table.FullName(), tableFiles(table))
+	}); err != nil {
 		return err
 	}
 	fmt.Printf("Inserted table: %s\n", table.FullName())
@@ -116,6 +131,10 @@ func insertTable(database, name string) error {
 }
 
 func insertRecord(database, table, name string) error {
+	attrs, err := mergeAttributes(nil, insertAttrs)
+	if err != nil {
+		return err
+	}
 	record := &model.Record{
 		Database:     database,
 		Table:        table,
@@ -132,13 +151,47 @@ func insertRecord(database, table, name string) error {
 		DescFile:     insertDescFile,
 		DescTag:      insertDescTag,
 		LogFile:      insertLogFile,
+		Tags:         insertTags,
+		Attributes:   attrs,
 		CreateAt:     time.Now(),
 		ModifyAt:     time.Now(),
 	}
 
-	if err := Store.InsertRecord(record); err != nil {
+	if err := Store.WithTx(func(repo store.Repository) error {
+		if err := repo.InsertRecord(record); err != nil {
+			return err
+		}
+		return trackFiles(repo, record.FullName(), recordFiles(record))
+	}); err != nil {
 		return err
 	}
 	fmt.Printf("Inserted record: %s\n", record.FullName())
 	return nil
 }
+
+// mergeAttributes applies key=value arguments to a copy of attrs
+// An empty value removes the key
+func mergeAttributes(attrs map[string]string, args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return attrs, nil
+	}
+	merged := make(map[string]string, len(attrs)+len(args))
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	for _, arg := range args {
+		key, value, err := store.ParseAttribute(arg)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	lineageDerivedFrom []string
+	lineageScripts     []string
+	lineageUpstream    bool
+	lineageDownstream  bool
+	lineageFormat      string
+)
+
+// lineageCmd groups the lineage graph subcommands
+var lineageCmd = &cobra.Command{
+	Use:   "lineage",
+	Short: "Manage the lineage graph between tables and records",
+	Long: `Record which derived datasets came from which raw ones and which scripts produced them.
+Trace a result back to its raw inputs (--upstream) or find everything affected
+by a change to an input (--downstream).`,
+}
+
+var lineageAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Record upstreams of a table or record",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		edges := lineageEdges(args[0])
+		if len(edges) == 0 {
+			return fmt.Errorf("--derived-from or --script is required")
+		}
+		if err := Store.WithTx(func(repo store.Repository) error {
+			for _, e := range edges {
+				if err := repo.AddLineage(&e); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, e := range edges {
+			fmt.Printf("Added lineage: %s %s %s\n", e.Name, e.Relation, e.Upstream)
+		}
+		return nil
+	},
+}
+
+var lineageRemoveCmd = &cobra.Command{
+	Use:   "remove [name]",
+	Short: "Remove upstreams of a table or record",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		edges := lineageEdges(args[0])
+		if len(edges) == 0 {
+			return fmt.Errorf("--derived-from or --script is required")
+		}
+		if err := Store.WithTx(func(repo store.Repository) error {
+			for _, e := range edges {
+				if err := repo.RemoveLineage(&e); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, e := range edges {
+			fmt.Printf("Removed lineage: %s %s %s\n", e.Name, e.Relation, e.Upstream)
+		}
+		return nil
+	},
+}
+
+var lineageShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show the lineage graph of a table or record",
+	Long: `Show the lineage graph of a table or record.
+Walks upstream by default; use --downstream for dependents, or both flags for the full graph.
+Formats: text, dot (Graphviz), mermaid.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		upstream := lineageUpstream || !lineageDownstream
+
+		var edges []model.LineageEdge
+		if upstream {
+			up, err := Store.Lineage(name, false)
+			if err != nil {
+				return err
+			}
+			edges = append(edges, up...)
+		}
+		if lineageDownstream {
+			down, err := Store.Lineage(name, true)
+			if err != nil {
+				return err
+			}
+			edges = append(edges, down...)
+		}
+
+		switch lineageFormat {
+		case "text":
+			writeLineageText(os.Stdout, name, edges)
+		case "dot":
+			writeLineageDOT(os.Stdout, name, edges)
+		case "mermaid":
+			writeLineageMermaid(os.Stdout, name, edges)
+		default:
+			return fmt.Errorf("unsupported format: %s (use text, dot or mermaid)", lineageFormat)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lineageCmd)
+	lineageCmd.AddCommand(lineageAddCmd)
+	lineageCmd.AddCommand(lineageRemoveCmd)
+	lineageCmd.AddCommand(lineageShowCmd)
+
+	for _, c := range []*cobra.Command{lineageAddCmd, lineageRemoveCmd} {
+		c.Flags().StringSliceVar(&lineageDerivedFrom, "derived-from", nil, "Upstream table or record (repeatable)")
+		c.Flags().StringSliceVar(&lineageScripts, "script", nil, "Script file that produced the item (repeatable)")
+	}
+
+	lineageShowCmd.Flags().BoolVar(&lineageUpstream, "upstream", false, "Walk towards the raw inputs (default)")
+	lineageShowCmd.Flags().BoolVar(&lineageDownstream, "downstream", false, "Walk towards derived items")
+	lineageShowCmd.Flags().StringVar(&lineageFormat, "format", "text", "Output format (text, dot, mermaid)")
+}
+
+// lineageEdges builds the edges described by --derived-from and --script
+func lineageEdges(name string) []model.LineageEdge {
+	var edges []model.LineageEdge
+	for _, up := range lineageDerivedFrom {
+		edges = append(edges, model.LineageEdge{Name: name, Upstream: up, Relation: model.RelationDerivedFrom})
+	}
+	for _, script := range lineageScripts {
+		edges = append(edges, model.LineageEdge{Name: name, Upstream: script, Relation: model.RelationProducedByScript})
+	}
+	return edges
+}
+
+// uniqueEdges drops duplicate edges while preserving order
+func uniqueEdges(edges []model.LineageEdge) []model.LineageEdge {
+	seen := make(map[model.LineageEdge]bool, len(edges))
+	var out []model.LineageEdge
+	for _, e := range edges {
+		key := model.LineageEdge{Name: e.Name, Upstream: e.Upstream, Relation: e.Relation}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, e)
+	}
+	return out
+}
+
+// writeLineageText prints one "upstream --relation--> derived" line per edge
+func writeLineageText(w io.Writer, name string, edges []model.LineageEdge) {
+	edges = uniqueEdges(edges)
+	if len(edges) == 0 {
+		fmt.Fprintf(w, "No lineage recorded for %s\n", name)
+		return
+	}
+	for _, e := range edges {
+		fmt.Fprintf(w, "%s %s %s\n", e.Upstream, Colorize(Gray, "--"+e.Relation+"-->"), e.Name)
+	}
+}
+
+// writeLineageDOT renders the edges as a Graphviz digraph
+// Scripts are drawn as notes, the queried item is highlighted
+func writeLineageDOT(w io.Writer, name string, edges []model.LineageEdge) {
+	edges = uniqueEdges(edges)
+	fmt.Fprintln(w, "digraph lineage {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, "  node [shape=box];")
+	fmt.Fprintf(w, "  %s [style=bold];\n", dotQuote(name))
+	scripts := make(map[string]bool)
+	for _, e := range edges {
+		if e.Relation == model.RelationProducedByScript && !scripts[e.Upstream] {
+			scripts[e.Upstream] = true
+			fmt.Fprintf(w, "  %s [shape=note];\n", dotQuote(e.Upstream))
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(w, "  %s -> %s [label=%s];\n", dotQuote(e.Upstream), dotQuote(e.Name), dotQuote(e.Relation))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// writeLineageMermaid renders the edges as a Mermaid flowchart
+// Node ids are generated because full names contain characters Mermaid rejects
+func writeLineageMermaid(w io.Writer, name string, edges []model.LineageEdge) {
+	edges = uniqueEdges(edges)
+	ids := make(map[string]string)
+	node := func(label string, script bool) string {
+		if id, ok := ids[label]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(ids))
+		ids[label] = id
+		if script {
+			fmt.Fprintf(w, "  %s[/%s/]\n", id, mermaidQuote(label))
+		} else {
+			fmt.Fprintf(w, "  %s[%s]\n", id, mermaidQuote(label))
+		}
+		return id
+	}
+
+	fmt.Fprintln(w, "graph LR")
+	node(name, false)
+	for _, e := range edges {
+		from := node(e.Upstream, e.Relation == model.RelationProducedByScript)
+		to := node(e.Name, false)
+		fmt.Fprintf(w, "  %s -->|%s| %s\n", from, e.Relation, to)
+	}
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
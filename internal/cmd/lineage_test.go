@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"srdm/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestLineageDOT(t *testing.T) {
+	edges := []model.LineageEdge{
+		{Name: "db:clean", Upstream: "db:raw", Relation: model.RelationDerivedFrom},
+		{Name: "db:clean", Upstream: "clean.R", Relation: model.RelationProducedByScript},
+	}
+
+	var buf bytes.Buffer
+	writeLineageDOT(&buf, "db:clean", edges)
+	out := buf.String()
+
+	if !strings.Contains(out, `"db:raw" -> "db:clean" [label="derived_from"];`) {
+		t.Errorf("DOT output missing derived_from edge:\n%s", out)
+	}
+	if !strings.Contains(out, `"clean.R" [shape=note];`) {
+		t.Errorf("DOT output missing script node:\n%s", out)
+	}
+}
+
+func TestLineageMermaid(t *testing.T) {
+	edges := []model.LineageEdge{
+		{Name: "db:clean", Upstream: "db:raw", Relation: model.RelationDerivedFrom},
+	}
+
+	var buf bytes.Buffer
+	writeLineageMermaid(&buf, "db:clean", edges)
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "graph LR") || !strings.Contains(out, `n1 -->|derived_from| n0`) {
+		t.Errorf("Unexpected Mermaid output:\n%s", out)
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"srdm/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var migrateStatus bool
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the repository schema",
+	Long: `Report the schema version of the repository and apply pending migrations.
+All pending migrations run inside a single transaction.
+Use --status to only report without changing the repository.`,
+	Args: cobra.NoArgs,
+	// Overrides the root hook: the repository must not be upgraded before reporting
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		initLogger()
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := resolveRepoPath()
+		if err != nil {
+			return err
+		}
+
+		db, err := store.OpenDB(path)
+		if err != nil {
+			return fmt.Errorf("could not open database at %s: %w", path, err)
+		}
+		defer db.Close()
+
+		current, err := db.SchemaVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %d (latest: %d)\n", Colorize(Cyan, "Schema version:"), current, store.LatestSchemaVersion())
+
+		pending, err := db.PendingMigrations()
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("Repository is up to date.")
+			return nil
+		}
+
+		fmt.Println(Colorize(Cyan, "Pending migrations:"))
+		for _, m := range pending {
+			fmt.Printf("  %3d  %s\n", m.Version, m.Description)
+		}
+		if migrateStatus {
+			return nil
+		}
+
+		applied, err := db.Migrate()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s), schema version is now %d\n", len(applied), store.LatestSchemaVersion())
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().BoolVar(&migrateStatus, "status", false, "Only report the schema version and pending migrations")
+}
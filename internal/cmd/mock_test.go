@@ -2,24 +2,48 @@ package cmd
 
 import (
 	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
 	"srdm/internal/model"
+	"srdm/internal/schema"
+	"srdm/internal/store"
+	"strings"
+	"time"
 )
 
 type MockRepository struct {
 	Tables  map[string]*model.Table
 	Records map[string]*model.Record
+	Edges   []model.LineageEdge
+	Sums    map[string]model.Checksum
+	Schemas map[string]string
+	Trashed []mockTrash
+
+	ChecksumErr error       // Returned by SaveChecksum when set
+	LastQuery   store.Query // Last query given to FilterRecords or FilterTables
+}
+
+// mockTrash is a deleted table with its records, or a single record
+type mockTrash struct {
+	Item    model.TrashItem
+	Table   *model.Table
+	Records []*model.Record
 }
 
 func NewMockRepository() *MockRepository {
 	return &MockRepository{
 		Tables:  make(map[string]*model.Table),
 		Records: make(map[string]*model.Record),
+		Sums:    make(map[string]model.Checksum),
+		Schemas: make(map[string]string),
 	}
 }
 
 func (m *MockRepository) InsertTable(t *model.Table) error {
 	if _, exists := m.Tables[t.FullName()]; exists {
-		return fmt.Errorf("table already exists")
+		return fmt.Errorf("%w: %s", store.ErrAlreadyExists, t.FullName())
 	}
 	m.Tables[t.FullName()] = t
 	return nil
@@ -27,7 +51,7 @@ func (m *MockRepository) InsertTable(t *model.Table) error {
 
 func (m *MockRepository) InsertRecord(r *model.Record) error {
 	if _, exists := m.Records[r.FullName()]; exists {
-		return fmt.Errorf("record already exists")
+		return fmt.Errorf("%w: %s", store.ErrAlreadyExists, r.FullName())
 	}
 	m.Records[r.FullName()] = r
 	return nil
@@ -37,19 +61,19 @@ func (m *MockRepository) GetTable(name string) (*model.Table, error) {
 	if t, exists := m.Tables[name]; exists {
 		return t, nil
 	}
-	return nil, nil // Not found but no error
+	return nil, fmt.Errorf("%w: %s", store.ErrNotFound, name)
 }
 
 func (m *MockRepository) GetRecord(name string) (*model.Record, error) {
 	if r, exists := m.Records[name]; exists {
 		return r, nil
 	}
-	return nil, nil
+	return nil, fmt.Errorf("%w: %s", store.ErrNotFound, name)
 }
 
 func (m *MockRepository) UpdateTable(t *model.Table) error {
 	if _, exists := m.Tables[t.FullName()]; !exists {
-		return fmt.Errorf("table not found")
+		return fmt.Errorf("%w: %s", store.ErrNotFound, t.FullName())
 	}
 	m.Tables[t.FullName()] = t
 	return nil
@@ -57,7 +81,7 @@ func (m *MockRepository) UpdateTable(t *model.Table) error {
 
 func (m *MockRepository) UpdateRecord(r *model.Record) error {
 	if _, exists := m.Records[r.FullName()]; !exists {
-		return fmt.Errorf("record not found")
+		return fmt.Errorf("%w: %s", store.ErrNotFound, r.FullName())
 	}
 	m.Records[r.FullName()] = r
 	return nil
@@ -92,27 +116,390 @@ func (m *MockRepository) SearchRecords(pattern string) ([]model.Record, error) {
 	return records, nil
 }
 
+func (m *MockRepository) SearchText(query string, limit int) ([]model.TextHit, error) {
+	var hits []model.TextHit
+	for name, r := range m.Records {
+		if strings.Contains(r.Description, query) {
+			hits = append(hits, model.TextHit{Name: name, Kind: "record", Snippet: r.Description})
+		}
+	}
+	return hits, nil
+}
+
+func (m *MockRepository) FilterRecords(q store.Query) ([]model.Record, error) {
+	// The mock cannot evaluate SQL; return every record in name order
+	m.LastQuery = q
+	var records []model.Record
+	for _, r := range m.Records {
+		records = append(records, *r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].FullName() < records[j].FullName() })
+	return records, nil
+}
+
+func (m *MockRepository) FilterTables(q store.Query) ([]model.Table, error) {
+	m.LastQuery = q
+	var tables []model.Table
+	for _, t := range m.Tables {
+		tables = append(tables, *t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].FullName() < tables[j].FullName() })
+	return tables, nil
+}
+
 func (m *MockRepository) Delete(name string, force bool) error {
 	// Try as table
-	if _, exists := m.Tables[name]; exists {
+	if t, exists := m.Tables[name]; exists {
 		if !force {
-			return fmt.Errorf("cannot delete table %s without force flag", name)
+			for k := range m.Records {
+				if strings.HasPrefix(k, name+":") {
+					return fmt.Errorf("%w: table %s has records", store.ErrHasChildren, name)
+				}
+			}
 		}
+		trash := mockTrash{Table: t}
 		delete(m.Tables, name)
 		// Delete children (very simple impl)
-		for k := range m.Records {
+		for k, r := range m.Records {
 			if len(k) > len(name) && k[:len(name)+1] == name+":" {
+				trash.Records = append(trash.Records, r)
 				delete(m.Records, k)
 			}
 		}
+		m.trash(name, model.KindTable, trash)
 		return nil
 	}
 
 	// Try as record
-	if _, exists := m.Records[name]; exists {
+	if r, exists := m.Records[name]; exists {
 		delete(m.Records, name)
+		m.trash(name, model.KindRecord, mockTrash{Records: []*model.Record{r}})
+		return nil
+	}
+	return fmt.Errorf("%w: %s", store.ErrNotFound, name)
+}
+
+func (m *MockRepository) Rename(oldName, newName string) error {
+	parts, err := store.SplitName(newName)
+	if err != nil {
+		return err
+	}
+	if _, exists := m.Tables[newName]; exists {
+		return fmt.Errorf("%w: %s", store.ErrAlreadyExists, newName)
+	}
+	if _, exists := m.Records[newName]; exists {
+		return fmt.Errorf("%w: %s", store.ErrAlreadyExists, newName)
+	}
+	if t, exists := m.Tables[oldName]; exists && len(parts) == 2 {
+		delete(m.Tables, oldName)
+		t.Database, t.Name = parts[0], parts[1]
+		m.Tables[newName] = t
+		for k, r := range m.Records {
+			if strings.HasPrefix(k, oldName+":") {
+				delete(m.Records, k)
+				r.Database, r.Table = parts[0], parts[1]
+				m.Records[r.FullName()] = r
+			}
+		}
+		return nil
+	}
+	if r, exists := m.Records[oldName]; exists && len(parts) == 3 {
+		if _, ok := m.Tables[parts[0]+":"+parts[1]]; !ok {
+			return fmt.Errorf("%w: %s:%s", store.ErrNotFound, parts[0], parts[1])
+		}
+		delete(m.Records, oldName)
+		r.Database, r.Table, r.Name = parts[0], parts[1], parts[2]
+		m.Records[newName] = r
+		return nil
+	}
+	return fmt.Errorf("%w: %s", store.ErrNotFound, oldName)
+}
+
+func (m *MockRepository) AddTags(name string, tags ...string) error {
+	if t, exists := m.Tables[name]; exists {
+		t.Tags = store.NormalizeTags(append(t.Tags, tags...))
 		return nil
 	}
+	if r, exists := m.Records[name]; exists {
+		r.Tags = store.NormalizeTags(append(r.Tags, tags...))
+		return nil
+	}
+	return fmt.Errorf("%w: %s", store.ErrNotFound, name)
+}
+
+func (m *MockRepository) RemoveTags(name string, tags ...string) error {
+	drop := func(current []string) []string {
+		var kept []string
+		for _, c := range current {
+			if !slices.Contains(tags, c) {
+				kept = append(kept, c)
+			}
+		}
+		return kept
+	}
+	if t, exists := m.Tables[name]; exists {
+		t.Tags = drop(t.Tags)
+		return nil
+	}
+	if r, exists := m.Records[name]; exists {
+		r.Tags = drop(r.Tags)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", store.ErrNotFound, name)
+}
+
+func (m *MockRepository) ListTags(name string) ([]string, error) {
+	if t, exists := m.Tables[name]; exists {
+		return t.Tags, nil
+	}
+	if r, exists := m.Records[name]; exists {
+		return r.Tags, nil
+	}
+	return nil, nil
+}
+
+func (m *MockRepository) TaggedNames(tags ...string) ([]string, error) {
+	var names []string
+	for name, t := range m.Tables {
+		for _, tag := range tags {
+			if slices.Contains(t.Tags, tag) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	for name, r := range m.Records {
+		for _, tag := range tags {
+			if slices.Contains(r.Tags, tag) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (m *MockRepository) AddLineage(e *model.LineageEdge) error {
+	m.Edges = append(m.Edges, *e)
+	return nil
+}
+
+func (m *MockRepository) RemoveLineage(e *model.LineageEdge) error {
+	for i, edge := range m.Edges {
+		if edge.Name == e.Name && edge.Upstream == e.Upstream && edge.Relation == e.Relation {
+			m.Edges = append(m.Edges[:i], m.Edges[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: lineage %s %s %s", store.ErrNotFound, e.Name, e.Relation, e.Upstream)
+}
+
+func (m *MockRepository) Lineage(name string, downstream bool) ([]model.LineageEdge, error) {
+	// Breadth-first walk over the in-memory edges
+	visited := map[string]bool{name: true}
+	queue := []string{name}
+	var edges []model.LineageEdge
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, e := range m.Edges {
+			from, to := e.Name, e.Upstream
+			if downstream {
+				from, to = e.Upstream, e.Name
+			}
+			if from != node {
+				continue
+			}
+			edges = append(edges, e)
+			if !visited[to] {
+				visited[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+	return edges, nil
+}
+
+func (m *MockRepository) History(name string) ([]model.HistoryEntry, error) {
+	return nil, nil
+}
+
+func (m *MockRepository) Revert(name string, version int) error {
+	return fmt.Errorf("revert not supported by mock")
+}
+
+func (m *MockRepository) SaveChecksum(c *model.Checksum) error {
+	if m.ChecksumErr != nil {
+		return m.ChecksumErr
+	}
+	m.Sums[c.Name+"|"+c.Field] = *c
+	return nil
+}
+
+func (m *MockRepository) RemoveChecksum(name, field string) error {
+	delete(m.Sums, name+"|"+field)
+	return nil
+}
+
+func (m *MockRepository) Checksum(name, field string) (*model.Checksum, error) {
+	c, ok := m.Sums[name+"|"+field]
+	if !ok {
+		return nil, fmt.Errorf("%w: checksum %s %s", store.ErrNotFound, name, field)
+	}
+	return &c, nil
+}
+
+func (m *MockRepository) Checksums(pattern string) ([]model.Checksum, error) {
+	var sums []model.Checksum
+	for _, c := range m.Sums {
+		self, _ := path.Match(pattern, c.Name)
+		child, _ := path.Match(pattern+":*", c.Name)
+		if self || child {
+			sums = append(sums, c)
+		}
+	}
+	sort.Slice(sums, func(i, j int) bool { return sums[i].Name+sums[i].Field < sums[j].Name+sums[j].Field })
+	return sums, nil
+}
+
+func (m *MockRepository) Orphans() ([]model.Record, error) {
+	var records []model.Record
+	for _, r := range m.Records {
+		if _, ok := m.Tables[r.Database+":"+r.Table]; !ok {
+			records = append(records, *r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].FullName() < records[j].FullName() })
+	return records, nil
+}
+
+func (m *MockRepository) SetSchema(table, rules string) error {
+	if _, err := schema.Parse(rules); err != nil {
+		return err
+	}
+	if _, exists := m.Tables[table]; !exists {
+		return fmt.Errorf("%w: %s", store.ErrNotFound, table)
+	}
+	if strings.TrimSpace(rules) == "" {
+		delete(m.Schemas, table)
+		return nil
+	}
+	m.Schemas[table] = rules
+	return nil
+}
+
+func (m *MockRepository) GetSchema(table string) (string, error) {
+	return m.Schemas[table], nil
+}
+
+func (m *MockRepository) Validate(pattern string) ([]schema.Violation, error) {
+	var out []schema.Violation
+	for _, name := range slices.Sorted(maps.Keys(m.Records)) {
+		r := m.Records[name]
+		rules, ok := m.Schemas[r.Database+":"+r.Table]
+		if !ok {
+			continue
+		}
+		s, err := schema.Parse(rules)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s.Check(r)...)
+	}
+	return out, nil
+}
+
+func (m *MockRepository) trash(name, kind string, t mockTrash) {
+	t.Item = model.TrashItem{ID: int64(len(m.Trashed) + 1), Name: name, Kind: kind, DeletedAt: time.Now()}
+	if kind == model.KindTable {
+		t.Item.Records = len(t.Records)
+	}
+	m.Trashed = append(m.Trashed, t)
+}
+
+func (m *MockRepository) Trash() ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	for i := len(m.Trashed) - 1; i >= 0; i-- {
+		items = append(items, m.Trashed[i].Item)
+	}
+	return items, nil
+}
+
+func (m *MockRepository) Restore(name string, id int64) error {
+	for i := len(m.Trashed) - 1; i >= 0; i-- {
+		t := m.Trashed[i]
+		if t.Item.Name != name || (id > 0 && t.Item.ID != id) {
+			continue
+		}
+		if _, exists := m.Tables[name]; exists {
+			return fmt.Errorf("%w: %s", store.ErrAlreadyExists, name)
+		}
+		if _, exists := m.Records[name]; exists {
+			return fmt.Errorf("%w: %s", store.ErrAlreadyExists, name)
+		}
+		if t.Table != nil {
+			m.Tables[name] = t.Table
+		}
+		for _, r := range t.Records {
+			m.Records[r.FullName()] = r
+		}
+		m.Trashed = slices.Delete(m.Trashed, i, i+1)
+		return nil
+	}
+	return fmt.Errorf("%w: %s in trash", store.ErrNotFound, name)
+}
+
+func (m *MockRepository) PurgeTrash(before time.Time) (int, error) {
+	n := len(m.Trashed)
+	m.Trashed = slices.DeleteFunc(m.Trashed, func(t mockTrash) bool { return t.Item.DeletedAt.Before(before) })
+	return n - len(m.Trashed), nil
+}
+
+func (m *MockRepository) Import(items []store.ImportItem, opts store.ImportOptions) ([]store.ImportResult, error) {
+	tables, records := maps.Clone(m.Tables), maps.Clone(m.Records)
+	results := make([]store.ImportResult, len(items))
+	failed := false
+	for i, it := range items {
+		res := store.ImportResult{Row: it.Row, Name: it.Name(), Action: store.ImportInserted}
+		_, tableExists := m.Tables[res.Name]
+		_, recordExists := m.Records[res.Name]
+		if tableExists || recordExists {
+			switch opts.OnConflict {
+			case store.ConflictSkip:
+				res.Action = store.ImportSkipped
+			case store.ConflictUpdate:
+				res.Action = store.ImportUpdated
+			default:
+				res.Action, res.Err = store.ImportFailed, fmt.Errorf("%w: %s", store.ErrAlreadyExists, res.Name)
+			}
+		}
+		if res.Action == store.ImportInserted || res.Action == store.ImportUpdated {
+			if it.Table != nil {
+				m.Tables[res.Name] = it.Table
+			} else {
+				m.Records[res.Name] = it.Record
+			}
+		}
+		failed = failed || res.Err != nil
+		results[i] = res
+	}
+	if failed || opts.DryRun {
+		m.Tables, m.Records = tables, records
+	}
+	if failed {
+		return results, store.ErrImportFailed
+	}
+	return results, nil
+}
+
+// WithTx restores the previous state when fn fails
+func (m *MockRepository) WithTx(fn func(store.Repository) error) error {
+	tables, records, edges, sums := maps.Clone(m.Tables), maps.Clone(m.Records), slices.Clone(m.Edges), maps.Clone(m.Sums)
+	if err := fn(m); err != nil {
+		m.Tables, m.Records, m.Edges, m.Sums = tables, records, edges, sums
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"srdm/internal/model"
+	"srdm/internal/profile"
+	"srdm/internal/store"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileSource    string
+	profileDelimiter string
+	profileHeader    string
+	profileDryRun    bool
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile [db:table]",
+	Short: "Fill record statistics from the table's data file",
+	Long: `Read the data file a table points at and create or update one record per column,
+with the inferred type, row count, missing count and distinct count.
+
+The table's engine decides how the file is read (SQLite3, CSV or TSV).
+CSV and TSV files are streamed once; the delimiter and header row are detected
+unless --delimiter or --header is given.
+Other fields of existing records, such as labels and descriptions, are kept.
+Records get no source: the data file is the table's, and 'srdm get' would
+otherwise copy it once per column. A source equal to the table's file, left by
+earlier versions, is cleared.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, err := Store.GetTable(args[0])
+		if err != nil {
+			return err
+		}
+
+		opts := profile.Options{SourceTable: profileSource, Header: profileHeader}
+		switch profileHeader {
+		case profile.HeaderAuto, profile.HeaderYes, profile.HeaderNo:
+		default:
+			return fmt.Errorf("invalid --header %q (use auto, yes or no)", profileHeader)
+		}
+		if profileDelimiter != "" {
+			d := []rune(strings.ReplaceAll(profileDelimiter, `\t`, "\t"))
+			if len(d) != 1 {
+				return fmt.Errorf("--delimiter must be a single character")
+			}
+			opts.Delimiter = d[0]
+		}
+
+		columns, err := profile.Table(t, opts)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			fmt.Fprintf(os.Stderr, "no columns found in %s\n", t.Path)
+			return nil
+		}
+
+		if !profileDryRun {
+			if err := Store.WithTx(func(repo store.Repository) error {
+				return saveProfile(repo, t, columns)
+			}); err != nil {
+				return err
+			}
+		}
+		return printProfile(t, columns)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+
+	profileCmd.Flags().StringVar(&profileSource, "source-table", "", "Table name inside the data file (default: the table's name)")
+	profileCmd.Flags().StringVar(&profileDelimiter, "delimiter", "", "Delimiter of CSV/TSV files, e.g. ';' or '\\t' (default: detected)")
+	profileCmd.Flags().StringVar(&profileHeader, "header", "auto", "Whether CSV/TSV files have a header row (auto, yes, no)")
+	profileCmd.Flags().BoolVar(&profileDryRun, "dry-run", false, "Print the profile without updating records")
+}
+
+// saveProfile creates or updates the record of each profiled column
+func saveProfile(repo store.Repository, t *model.Table, columns []profile.Column) error {
+	now := time.Now()
+	for _, c := range columns {
+		if strings.Contains(c.Name, ":") {
+			return fmt.Errorf("column name %q cannot be used as a record name", c.Name)
+		}
+		name := t.FullName() + ":" + c.Name
+		r, err := repo.GetRecord(name)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+
+		exists := err == nil
+		if !exists {
+			r = &model.Record{
+				Database: t.Database,
+				Table:    t.Name,
+				Name:     c.Name,
+				CreateAt: now,
+				ModifyAt: now,
+			}
+		}
+		r.Type, r.Number, r.MissNumber, r.UniqueNumber = c.Type, c.Number, c.MissNumber, c.UniqueNumber
+		// Earlier versions set the table's file as the source of every column
+		staleSource := exists && t.Path != "" && r.Source == t.Path
+		if staleSource {
+			r.Source = ""
+		}
+
+		if exists {
+			err = repo.UpdateRecord(r)
+		} else {
+			err = repo.InsertRecord(r)
+		}
+		if err != nil {
+			return err
+		}
+		if staleSource {
+			if err := repo.RemoveChecksum(name, model.FileFieldSource); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// printProfile prints the column statistics as an aligned table
+func printProfile(t *model.Table, columns []profile.Column) error {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RECORD\tTYPE\tN\tMISS\tUNIQUE")
+	for _, c := range columns {
+		fmt.Fprintf(w, "%s:%s\t%s\t%d\t%d\t%d\n", t.FullName(), c.Name, c.Type, c.Number, c.MissNumber, c.UniqueNumber)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	header, rows, _ := strings.Cut(buf.String(), "\n")
+	fmt.Println(Colorize(Cyan, header))
+	fmt.Print(rows)
+	return nil
+}
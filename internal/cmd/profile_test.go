@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestProfileCSV(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() { Store = nil }()
+
+	path := filepath.Join(t.TempDir(), "people.csv")
+	if err := os.WriteFile(path, []byte("id,age\n1,30\n2,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "people", Engine: "CSV", Path: path})
+
+	rootCmd.SetArgs([]string{"profile", "db:people"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Profile failed: %v", err)
+	}
+	age := mockStore.Records["db:people:age"]
+	if age == nil || age.Number != 2 || age.MissNumber != 1 {
+		t.Fatalf("Column age not profiled: %+v", age)
+	}
+	// The data file is the table's, so get does not copy it once per column
+	if age.Source != "" {
+		t.Errorf("Profiled record should have no source, got %q", age.Source)
+	}
+}
+
+func TestProfileClearsStaleSource(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() { Store = nil }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "people.csv")
+	notes := filepath.Join(dir, "age.txt")
+	if err := os.WriteFile(path, []byte("id,age\n1,30\n2,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "people", Engine: "CSV", Path: path})
+	// Records profiled by earlier versions carry the table's file as their source
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "people", Name: "id", Source: path})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "people", Name: "age", Source: notes})
+	mockStore.SaveChecksum(&model.Checksum{Name: "db:people:id", Field: model.FileFieldSource, Path: path})
+
+	rootCmd.SetArgs([]string{"profile", "db:people"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Profile failed: %v", err)
+	}
+	if id := mockStore.Records["db:people:id"]; id.Source != "" {
+		t.Errorf("Stale source not cleared: %q", id.Source)
+	}
+	if _, ok := mockStore.Sums["db:people:id|"+model.FileFieldSource]; ok {
+		t.Error("Checksum of the stale source not removed")
+	}
+	if age := mockStore.Records["db:people:age"]; age.Source != notes {
+		t.Errorf("Source of another file should be kept, got %q", age.Source)
+	}
+}
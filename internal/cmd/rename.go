@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"srdm/internal/store"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   "rename OLD NEW",
+	Short: "Rename a table or record",
+	Long: `Rename a table (db:table) or record (db:table:record) to a new full name.
+A renamed table takes its records along. Tags, lineage, checksums and history
+stay attached to the new names, and the rename is recorded in the history.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := Store.Rename(args[0], args[1]); err != nil {
+			return fmt.Errorf("failed to rename %s: %w", args[0], err)
+		}
+		fmt.Printf("Renamed: %s -> %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv SOURCE... DEST",
+	Short: "Move tables to another database or records to another table",
+	Long: `Move tables or records, keeping their names where the destination allows it.
+
+  srdm mv db:table newdb            move a table into database newdb
+  srdm mv db:t1:rec db:t2           move a record into the existing table db:t2
+  srdm mv db:table newdb:table2     same as rename
+
+Several sources can be moved into one database or table at once; either all of
+them are moved or none is.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sources, dest := args[:len(args)-1], args[len(args)-1]
+
+		targets := make([]string, len(sources))
+		for i, src := range sources {
+			target, err := moveTarget(src, dest, len(sources) > 1)
+			if err != nil {
+				return err
+			}
+			targets[i] = target
+		}
+
+		if err := Store.WithTx(func(repo store.Repository) error {
+			for i, src := range sources {
+				if err := repo.Rename(src, targets[i]); err != nil {
+					return fmt.Errorf("failed to move %s: %w", src, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for i, src := range sources {
+			fmt.Printf("Moved: %s -> %s\n", src, targets[i])
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(mvCmd)
+}
+
+// moveTarget resolves the new full name of src when moved to dest
+// A database destination keeps the table name, a table destination keeps the record name
+// With several sources dest must be such a container
+func moveTarget(src, dest string, multiple bool) (string, error) {
+	srcParts, err := store.SplitName(src)
+	if err != nil {
+		return "", err
+	}
+	destParts := strings.Split(strings.TrimSuffix(dest, ":"), ":")
+	for _, p := range destParts {
+		if p == "" {
+			return "", fmt.Errorf("%w: %q", store.ErrInvalidName, dest)
+		}
+	}
+
+	switch {
+	case len(srcParts) == 2 && len(destParts) == 1:
+		return destParts[0] + ":" + srcParts[1], nil
+	case len(srcParts) == 3 && len(destParts) == 2:
+		return strings.Join(destParts, ":") + ":" + srcParts[2], nil
+	case multiple:
+		return "", fmt.Errorf("%w: cannot move several items to %s: use a database for tables or a table for records",
+			store.ErrInvalidName, dest)
+	}
+	return dest, nil
+}
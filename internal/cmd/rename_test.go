@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestRenameAndMove(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() { Store = nil }()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t"})
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "u"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "b"})
+
+	rootCmd.SetArgs([]string{"rename", "db:t:a", "db:t:c"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if _, ok := mockStore.Records["db:t:c"]; !ok {
+		t.Error("Record was not renamed")
+	}
+
+	// Records keep their names when moved into a table
+	rootCmd.SetArgs([]string{"mv", "db:t:b", "db:t:c", "db:u"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("mv into table failed: %v", err)
+	}
+	for _, name := range []string{"db:u:b", "db:u:c"} {
+		if _, ok := mockStore.Records[name]; !ok {
+			t.Errorf("%s was not moved", name)
+		}
+	}
+
+	// Tables keep their names when moved into a database
+	rootCmd.SetArgs([]string{"mv", "db:u", "archive"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("mv into database failed: %v", err)
+	}
+	if _, ok := mockStore.Records["archive:u:b"]; !ok {
+		t.Error("Records did not follow their table")
+	}
+
+	rootCmd.SetArgs([]string{"mv", "db:gone", "archive:u", "other"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error moving a missing table")
+	}
+}
@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"srdm/internal/export"
 	"srdm/internal/store"
 
 	"github.com/spf13/cobra"
@@ -17,6 +19,27 @@ var (
 	DataRepoPath string
 )
 
+// Exit codes, so scripts can tell a missing item apart from a broken repository
+const (
+	ExitFailure       = 1 // Any other error, e.g. an unreadable or corrupt repository
+	ExitUsage         = 2 // Unknown flag or invalid flag value
+	ExitNotFound      = 3 // store.ErrNotFound
+	ExitAlreadyExists = 4 // store.ErrAlreadyExists
+	ExitHasChildren   = 5 // store.ErrHasChildren
+	ExitInvalidName   = 6 // store.ErrInvalidName
+	ExitSchema        = 7 // store.ErrSchemaViolation
+	ExitIncomplete    = 8 // export.ErrIncomplete
+	ExitDrift         = 9 // errDrift: files checked by verify changed or disappeared
+)
+
+// errUsage marks errors caused by how the command was invoked
+var errUsage = errors.New("usage error")
+
+// usageError keeps the message of a flag error while matching errUsage
+type usageError struct{ error }
+
+func (e usageError) Unwrap() []error { return []error{e.error, errUsage} }
+
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
 	Use:   "srdm",
@@ -30,26 +53,15 @@ It supports data insertion, update, deletion, viewing, extraction, query, and ex
 			return nil
 		}
 
-		// Initialize Logger
-		logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
-		slog.SetDefault(logger)
+		initLogger()
 
-		var err error
-		// If flag is not set, try getting from environment variable
-		if DataRepoPath == "" {
-			DataRepoPath = os.Getenv("SRDM_DATA_REPO_PATH")
-		}
-		// If env is also empty, use default path
-		if DataRepoPath == "" {
-			home, err := os.UserHomeDir()
-			if err != nil {
-				return err
-			}
-			DataRepoPath = filepath.Join(home, "Data", "SRDM", "srdm_dataRepo.sqlite")
+		path, err := resolveRepoPath()
+		if err != nil {
+			return err
 		}
 
 		// Initialize database connection
-		db, err := store.NewDB(DataRepoPath)
+		db, err := store.NewDB(path)
 		if err != nil {
 			return fmt.Errorf("could not initialize database at %s: %w", DataRepoPath, err)
 		}
@@ -58,15 +70,72 @@ It supports data insertion, update, deletion, viewing, extraction, query, and ex
 	},
 }
 
+// initLogger installs the default structured logger on stderr
+func initLogger() {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	slog.SetDefault(logger)
+}
+
+// resolveRepoPath determines the repository location
+// Priority: --path flag, SRDM_DATA_REPO_PATH environment variable, default path
+func resolveRepoPath() (string, error) {
+	// If flag is not set, try getting from environment variable
+	if DataRepoPath == "" {
+		DataRepoPath = os.Getenv("SRDM_DATA_REPO_PATH")
+	}
+	// If env is also empty, use default path
+	if DataRepoPath == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		DataRepoPath = filepath.Join(home, "Data", "SRDM", "srdm_dataRepo.sqlite")
+	}
+	return DataRepoPath, nil
+}
+
 // Execute executes the root command
+// Errors are printed once and mapped to an exit code by exitCode
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitCode(err))
 	}
 }
 
+// exitCode returns the process exit code for an error returned by a command
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, store.ErrAlreadyExists):
+		return ExitAlreadyExists
+	case errors.Is(err, store.ErrHasChildren):
+		return ExitHasChildren
+	case errors.Is(err, store.ErrInvalidName):
+		return ExitInvalidName
+	case errors.Is(err, store.ErrSchemaViolation):
+		return ExitSchema
+	case errors.Is(err, export.ErrIncomplete):
+		return ExitIncomplete
+	case errors.Is(err, errDrift):
+		return ExitDrift
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	}
+	return ExitFailure
+}
+
 func init() {
+	// Execute prints the error itself, with the exit code it maps to
+	// Usage is only shown for flag errors, not when a command fails
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		fmt.Fprint(os.Stderr, cmd.UsageString())
+		return usageError{err}
+	})
+
 	// Define global flags
 	rootCmd.PersistentFlags().StringVar(&DataRepoPath, "path", "", "Data storage location (default: $HOME/Documents/SRDM/srdm_dataRepo.sqlite)")
 }
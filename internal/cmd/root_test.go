@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestExitCodes(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		insertName, insertKeys, deleteForce = "", "", false
+	}()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t", Keys: "id"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "r"})
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"view", "db:missing"}, ExitNotFound},
+		{[]string{"insert", "--name", "db:t", "--keys", "id"}, ExitAlreadyExists},
+		{[]string{"delete", "db:t"}, ExitHasChildren},
+		{[]string{"insert", "--name", "nocolon"}, ExitInvalidName},
+		{[]string{"view", "--bogus"}, ExitUsage},
+	}
+	for _, tt := range tests {
+		rootCmd.SetArgs(tt.args)
+		err := rootCmd.Execute()
+		if err == nil {
+			t.Errorf("%v: expected an error", tt.args)
+			continue
+		}
+		if got := exitCode(err); got != tt.want {
+			t.Errorf("%v: exit code %d, want %d (%v)", tt.args, got, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"srdm/internal/checksum"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	scanInto    string
+	scanInclude []string
+	scanExclude []string
+	scanHidden  bool
+	scanDryRun  bool
+)
+
+// Outcomes of scanning one file
+const (
+	scanAdded     = "added"
+	scanUpdated   = "updated"
+	scanUnchanged = "unchanged"
+)
+
+// fileTypes maps lower-case file extensions to the record type of the file
+var fileTypes = map[string]string{
+	".csv":      "CSV",
+	".tsv":      "TSV",
+	".tab":      "TSV",
+	".txt":      "Text",
+	".json":     "JSON",
+	".jsonl":    "JSONL",
+	".yaml":     "YAML",
+	".yml":      "YAML",
+	".xml":      "XML",
+	".sqlite":   "SQLite3",
+	".sqlite3":  "SQLite3",
+	".db":       "SQLite3",
+	".parquet":  "Parquet",
+	".feather":  "Feather",
+	".arrow":    "Arrow",
+	".xls":      "Excel",
+	".xlsx":     "Excel",
+	".dta":      "Stata",
+	".sav":      "SPSS",
+	".sas7bdat": "SAS",
+	".rds":      "RDS",
+	".rdata":    "RData",
+	".fst":      "FST",
+	".h5":       "HDF5",
+	".hdf5":     "HDF5",
+	".nc":       "NetCDF",
+	".gz":       "Gzip",
+	".zip":      "Zip",
+}
+
+// scanCmd represents the scan command
+var scanCmd = &cobra.Command{
+	Use:   "scan DIR --into db:table",
+	Short: "Register the data files of a directory as records",
+	Long: `Walk DIR and keep one record per data file under the table given by --into.
+
+Each record is named after the file's path relative to DIR and its type is inferred
+from the extension. New files get a new record; files whose content changed since
+the last scan get their record updated and their checksum refreshed. Unchanged files
+are left alone, so scan can be re-run to keep the catalogue in sync.
+
+--include and --exclude take shell globs. A pattern containing '/' is matched against
+the relative path, any other pattern against the file or directory name. Excluding a
+directory skips everything below it. Hidden files are skipped unless --hidden is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scanInto == "" {
+			return fmt.Errorf("--into is required")
+		}
+		t, err := Store.GetTable(scanInto)
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("%w (create the table with insert first)", err)
+		}
+		if err != nil {
+			return err
+		}
+		for _, p := range append(append([]string{}, scanInclude...), scanExclude...) {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", p, err)
+			}
+		}
+
+		files, err := scanFiles(args[0])
+		if err != nil {
+			return err
+		}
+
+		counts := map[string]int{}
+		var changed [][2]string // Outcome and name of each added or updated record
+		err = Store.WithTx(func(repo store.Repository) error {
+			for _, f := range files {
+				outcome, err := scanFile(repo, t, f)
+				if err != nil {
+					return fmt.Errorf("%s: %w", f.rel, err)
+				}
+				counts[outcome]++
+				if outcome != scanUnchanged {
+					changed = append(changed, [2]string{outcome, t.FullName() + ":" + f.rel})
+				}
+			}
+			if scanDryRun {
+				return errScanDryRun
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errScanDryRun) {
+			return err
+		}
+
+		for _, c := range changed {
+			color := Yellow
+			if c[0] == scanAdded {
+				color = Green
+			}
+			fmt.Printf("%s %s\n", Colorize(color, fmt.Sprintf("%-7s", c[0])), c[1])
+		}
+		verb := "Scanned"
+		if scanDryRun {
+			verb = "Dry run: scanned"
+		}
+		fmt.Printf("%s %d files: %d added, %d updated, %d unchanged\n",
+			verb, len(files), counts[scanAdded], counts[scanUpdated], counts[scanUnchanged])
+		return nil
+	},
+}
+
+// errScanDryRun rolls back the changes of a dry run
+var errScanDryRun = errors.New("dry run")
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().StringVar(&scanInto, "into", "", "Table receiving the records (format: db:table)")
+	scanCmd.Flags().StringSliceVar(&scanInclude, "include", nil, "Only register files matching this glob (repeatable)")
+	scanCmd.Flags().StringSliceVar(&scanExclude, "exclude", nil, "Skip files and directories matching this glob (repeatable)")
+	scanCmd.Flags().BoolVar(&scanHidden, "hidden", false, "Include hidden files and directories")
+	scanCmd.Flags().BoolVar(&scanDryRun, "dry-run", false, "Report what would change without writing")
+}
+
+// scannedFile is a data file found below the scanned directory
+type scannedFile struct {
+	path string // Absolute path
+	rel  string // Slash-separated path relative to the scanned directory
+}
+
+// scanFiles lists the regular files below dir that pass the include and exclude globs
+func scanFiles(dir string) ([]scannedFile, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	if fi, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	var files []scannedFile
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if (!scanHidden && strings.HasPrefix(d.Name(), ".")) || matchGlob(scanExclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if len(scanInclude) > 0 && !matchGlob(scanInclude, rel) {
+			return nil
+		}
+		if strings.Contains(rel, ":") {
+			fmt.Fprintf(os.Stderr, "skipping %s: ':' cannot be used in a record name\n", rel)
+			return nil
+		}
+		files = append(files, scannedFile{path: p, rel: rel})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// matchGlob reports whether any pattern matches the relative path
+// Patterns without '/' are matched against the base name
+func matchGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		target := rel
+		if !strings.Contains(p, "/") {
+			target = path.Base(rel)
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fileType infers a record type from the file extension
+func fileType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if t, ok := fileTypes[ext]; ok {
+		return t
+	}
+	return strings.ToUpper(strings.TrimPrefix(ext, "."))
+}
+
+// scanFile creates or updates the record of one file and returns the outcome
+func scanFile(repo store.Repository, t *model.Table, f scannedFile) (string, error) {
+	name := t.FullName() + ":" + f.rel
+	r, err := repo.GetRecord(name)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return "", err
+	}
+	if err != nil {
+		now := time.Now()
+		r = &model.Record{
+			Database: t.Database,
+			Table:    t.Name,
+			Name:     f.rel,
+			Type:     fileType(f.rel),
+			Source:   f.path,
+			CreateAt: now,
+			ModifyAt: now,
+		}
+		if err := repo.InsertRecord(r); err != nil {
+			return "", err
+		}
+		return scanAdded, trackFiles(repo, name, recordFiles(r))
+	}
+
+	stored, err := sourceChecksum(repo, name)
+	if err != nil {
+		return "", err
+	}
+	if stored != nil && stored.Path == f.path {
+		current, changed, err := checksum.Refresh(*stored)
+		if err != nil {
+			return "", err
+		}
+		if !changed {
+			// Keep the new mtime of a touched file so the next scan need not re-hash it
+			if !current.ModTime.Equal(stored.ModTime) {
+				return scanUnchanged, repo.SaveChecksum(current)
+			}
+			return scanUnchanged, nil
+		}
+	}
+
+	r.Source = f.path
+	if r.Type == "" {
+		r.Type = fileType(f.rel)
+	}
+	if err := repo.UpdateRecord(r); err != nil {
+		return "", err
+	}
+	return scanUpdated, trackFiles(repo, name, recordFiles(r))
+}
+
+// sourceChecksum returns the stored checksum of a record's source file, or nil
+func sourceChecksum(repo store.Repository, name string) (*model.Checksum, error) {
+	c, err := repo.Checksum(name, model.FileFieldSource)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return c, err
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"srdm/internal/model"
+	"testing"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		scanInto, scanInclude, scanExclude, scanDryRun = "", nil, nil, false
+	}()
+	mockStore.InsertTable(&model.Table{Database: "p", Name: "files", Keys: "name"})
+
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("raw/wave1.csv", "a,b\n")
+	write("raw/wave1.dta", "stata")
+	write("tmp/scratch.csv", "x")
+	write(".cache/c.csv", "x")
+	write("README.md", "notes")
+
+	rootCmd.SetArgs([]string{"scan", dir, "--into", "p:files", "--exclude", "tmp", "--exclude", "*.md"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(mockStore.Records) != 2 {
+		t.Fatalf("Expected 2 records, got %v", mockStore.Records)
+	}
+	r := mockStore.Records["p:files:raw/wave1.dta"]
+	if r == nil || r.Type != "Stata" || r.Source != filepath.Join(dir, "raw", "wave1.dta") {
+		t.Errorf("Unexpected record: %+v", r)
+	}
+	if _, ok := mockStore.Sums["p:files:raw/wave1.csv|"+model.FileFieldSource]; !ok {
+		t.Error("Scan should record the file checksum")
+	}
+
+	// Only changed content updates a record
+	r.Label = "kept"
+	write("raw/wave1.csv", "a,b\n1,2\n")
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(dir, "raw", "wave1.dta"), future, future)
+	rootCmd.SetArgs([]string{"scan", dir, "--into", "p:files", "--include", "raw/*"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Rescan failed: %v", err)
+	}
+	c := mockStore.Sums["p:files:raw/wave1.csv|"+model.FileFieldSource]
+	if c.Size != 8 {
+		t.Errorf("Changed file should get a new checksum, got %+v", c)
+	}
+	if got := mockStore.Records["p:files:raw/wave1.dta"]; got.Label != "kept" {
+		t.Errorf("Unchanged file should keep its record, got %+v", got)
+	}
+
+	rootCmd.SetArgs([]string{"scan", dir, "--into", "p:missing"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for a missing table")
+	}
+}
+
+func TestScanGlobCharactersInFileNames(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		scanInto, scanInclude, scanExclude, scanDryRun = "", nil, nil, false
+	}()
+	mockStore.InsertTable(&model.Table{Database: "p", Name: "files", Keys: "name"})
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "wave[1].csv"), []byte("a,b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"scan", dir, "--into", "p:files"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+
+	// An unchanged file keeps its checksum, however its name reads as a glob
+	key := "p:files:wave[1].csv|" + model.FileFieldSource
+	c := mockStore.Sums[key]
+	c.CheckedAt = time.Time{}
+	mockStore.Sums[key] = c
+	rootCmd.SetArgs([]string{"scan", dir, "--into", "p:files"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Rescan failed: %v", err)
+	}
+	if got := mockStore.Sums[key]; !got.CheckedAt.IsZero() {
+		t.Errorf("Unchanged file was scanned as updated: %+v", got)
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"srdm/internal/schema"
+	"srdm/internal/store"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaRules []string
+
+// schemaCmd groups the validation schema subcommands
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Manage the validation rules of a table's records",
+	Long: `Attach validation rules to a table. Every record inserted, updated or imported
+into the table must satisfy them. One rule per line:
+
+  required units, label          the fields or attributes must have a value
+  type in int|float|string       the field must be one of the values
+  missNumber <= number           compare with another field or a literal
+  attr.license = CC-BY           operators: = != > >= < <= ~ (contains)
+
+Fields are record columns or custom attributes (attr.KEY, or a bare KEY that is
+not a column). Rules other than required only apply to fields that have a value.`,
+}
+
+var schemaSetCmd = &cobra.Command{
+	Use:   "set [table] [file]",
+	Short: "Set the rules of a table from a file, stdin (-) or --rule",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		table := args[0]
+		if len(args) == 2 && len(schemaRules) > 0 {
+			return fmt.Errorf("use either a rules file or --rule, not both")
+		}
+
+		rules := strings.Join(schemaRules, "\n")
+		if len(args) == 2 {
+			var in io.Reader = os.Stdin
+			if args[1] != "-" {
+				file, err := os.Open(args[1])
+				if err != nil {
+					return fmt.Errorf("failed to open rules file: %w", err)
+				}
+				defer file.Close()
+				in = file
+			}
+			data, err := io.ReadAll(in)
+			if err != nil {
+				return fmt.Errorf("failed to read rules: %w", err)
+			}
+			rules = string(data)
+		}
+		if strings.TrimSpace(rules) == "" {
+			return fmt.Errorf("no rules given (use 'schema clear' to remove a schema)")
+		}
+
+		if err := Store.SetSchema(table, rules); err != nil {
+			return err
+		}
+		fmt.Printf("Set schema of %s\n", table)
+
+		// The rules only guard future writes; point out records already breaking them
+		violations, err := Store.Validate(table + ":%")
+		if err != nil {
+			return err
+		}
+		if len(violations) > 0 {
+			fmt.Fprintln(os.Stderr, Colorize(Yellow, fmt.Sprintf(
+				"%d existing violations, run 'srdm validate %s:%%' to list them", len(violations), table)))
+		}
+		return nil
+	},
+}
+
+var schemaShowCmd = &cobra.Command{
+	Use:   "show [table]",
+	Short: "Print the rules of a table",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := Store.GetTable(args[0]); err != nil {
+			return err
+		}
+		rules, err := Store.GetSchema(args[0])
+		if err != nil {
+			return err
+		}
+		if rules == "" {
+			fmt.Fprintf(os.Stderr, "no schema: %s\n", args[0])
+			return nil
+		}
+		fmt.Println(strings.TrimRight(rules, "\n"))
+		return nil
+	},
+}
+
+var schemaClearCmd = &cobra.Command{
+	Use:   "clear [table]",
+	Short: "Remove the rules of a table",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := Store.SetSchema(args[0], ""); err != nil {
+			return err
+		}
+		fmt.Printf("Cleared schema of %s\n", args[0])
+		return nil
+	},
+}
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [pattern]",
+	Short: "Check records against the schemas of their tables",
+	Long: `Check every record matching the pattern (default: all) against the validation
+schema of its table and list the violations. Records of tables without a schema
+are skipped. The exit code is non-zero when any record breaks its schema.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := "%"
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+
+		violations, err := Store.Validate(pattern)
+		if err != nil {
+			return err
+		}
+		if len(violations) == 0 {
+			fmt.Println("No violations found.")
+			return nil
+		}
+
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tRULE\tPROBLEM")
+		for _, v := range violations {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", v.Name, v.Rule, v.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		header, rows, _ := strings.Cut(buf.String(), "\n")
+		fmt.Println(Colorize(Cyan, header))
+		fmt.Print(rows)
+
+		return fmt.Errorf("%w: %d violations in %d records", store.ErrSchemaViolation, len(violations), countNames(violations))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(validateCmd)
+	schemaCmd.AddCommand(schemaSetCmd, schemaShowCmd, schemaClearCmd)
+
+	schemaSetCmd.Flags().StringArrayVar(&schemaRules, "rule", nil, "Rule to set (repeatable)")
+}
+
+// countNames returns the number of distinct records with violations
+func countNames(violations []schema.Violation) int {
+	names := map[string]bool{}
+	for _, v := range violations {
+		names[v.Name] = true
+	}
+	return len(names)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestSchemaAndValidate(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		schemaRules = nil
+	}()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a", Type: "int"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "b", Type: "blob"})
+
+	rootCmd.SetArgs([]string{"schema", "set", "db:t", "--rule", "type in int|float|string"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("schema set failed: %v", err)
+	}
+	if mockStore.Schemas["db:t"] != "type in int|float|string" {
+		t.Errorf("Unexpected stored rules: %q", mockStore.Schemas["db:t"])
+	}
+
+	rootCmd.SetArgs([]string{"validate"})
+	err := rootCmd.Execute()
+	if err == nil {
+		t.Fatal("Expected validate to fail on db:t:b")
+	}
+	if got := exitCode(err); got != ExitSchema {
+		t.Errorf("Exit code %d, want %d", got, ExitSchema)
+	}
+
+	rootCmd.SetArgs([]string{"schema", "clear", "db:t"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("schema clear failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"validate"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Expected no violations without a schema, got %v", err)
+	}
+}
@@ -3,7 +3,13 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"srdm/internal/model"
+	"srdm/internal/query"
+	"srdm/internal/store"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -12,28 +18,58 @@ var (
 	searchMode       string
 	searchFormat     string
 	searchOutputFile string
+	searchTags       []string
+	searchAnyTags    []string
+	searchNoTags     []string
+	searchText       string
+	searchLimit      int
+	searchOffset     int
+	searchWhere      string
+	searchSort       string
+	searchTables     bool
 )
 
 // searchCmd represents the search command
 var searchCmd = &cobra.Command{
 	Use:   "search [names]",
 	Short: "Query data records",
-	Long:  `Query data records. Supports exact match by name or fuzzy search.`,
+	Long: `Query data records. Supports exact match by name or fuzzy search.
+
+Use --where for structured queries over record (or, with --tables, table) fields:
+
+  srdm search --where 'type=fastq AND number>1000 AND modified>2025-01-01 AND label~control'
+
+Operators: = != > >= < <= and ~ / !~ (contains). Combine with AND, OR, NOT and parentheses.
+Names given as arguments further restrict the query to those name prefixes, where _ and %
+match literally.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if searchLimit < 0 || searchOffset < 0 {
+			return usageError{fmt.Errorf("--limit and --offset must not be negative")}
+		}
+		if searchText != "" {
+			return runTextSearch()
+		}
+
 		var results []interface{}
+		paged := false // Whether the query already applied --limit and --offset
 
-		if len(args) > 0 {
+		if searchWhere != "" || searchSort != "" || searchTables {
+			var err error
+			if results, paged, err = filterSearch(args); err != nil {
+				return err
+			}
+		} else if len(args) > 0 {
 			for _, name := range args {
 				// Try fetching as Table
 				t, err := Store.GetTable(name)
-				if err == nil && t != nil {
+				if err == nil {
 					results = append(results, t)
 					continue
 				}
 
 				// Try fetching as Record
 				r, err := Store.GetRecord(name)
-				if err == nil && r != nil {
+				if err == nil {
 					results = append(results, r)
 					continue
 				}
@@ -49,37 +85,234 @@ var searchCmd = &cobra.Command{
 
 				fmt.Fprintf(os.Stderr, "not found: %s\n", name)
 			}
+			sortByName(results)
+		} else if len(searchTags) > 0 || len(searchAnyTags) > 0 {
+			// No names given: start from everything carrying one of the requested tags
+			names, err := Store.TaggedNames(append(searchTags, searchAnyTags...)...)
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				if t, err := Store.GetTable(name); err == nil {
+					results = append(results, t)
+				} else if r, err := Store.GetRecord(name); err == nil {
+					results = append(results, r)
+				}
+			}
+			sortByName(results)
 		} else {
 			// If no args provided, show help
 			return cmd.Help()
 		}
 
+		results = filterByTags(results)
+		if !paged {
+			results = page(results)
+		}
+
 		if len(results) == 0 {
 			return nil
 		}
 
-		// Output handling
+		return writeSearchOutput(len(results), func(w io.Writer, color bool) error {
+			return renderResults(w, results, color)
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringVar(&searchMode, "mode", "detail", "Display mode for text output (detail, name-only, oneline)")
+	searchCmd.Flags().StringVar(&searchFormat, "format", "json", "Output format (json, text)")
+	searchCmd.Flags().StringVar(&searchOutputFile, "output-file", "", "Write results to this file instead of stdout")
+	searchCmd.Flags().StringSliceVar(&searchTags, "tag", nil, "Only show items carrying all of these tags")
+	searchCmd.Flags().StringSliceVar(&searchAnyTags, "any-tag", nil, "Only show items carrying at least one of these tags")
+	searchCmd.Flags().StringSliceVar(&searchNoTags, "exclude-tag", nil, "Hide items carrying any of these tags")
+	searchCmd.Flags().StringVar(&searchText, "text", "", "Full-text search over names, descriptions, sources and labels")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 0, "Maximum number of results (0 = no limit)")
+	searchCmd.Flags().IntVar(&searchOffset, "offset", 0, "Number of results to skip")
+	searchCmd.Flags().StringVar(&searchWhere, "where", "", "Filter expression, e.g. 'type=fastq AND number>1000'")
+	searchCmd.Flags().StringVar(&searchSort, "sort", "", "Sort fields, e.g. '-modified,name' (- for descending)")
+	searchCmd.Flags().BoolVar(&searchTables, "tables", false, "Query tables instead of records")
+}
+
+// filterSearch runs a structured --where query with sorting and paging
+// Name arguments are added as alternative name prefixes. Paging is left to the
+// caller, as reported by paged, when tag filters still have to drop results
+func filterSearch(names []string) (results []interface{}, paged bool, err error) {
+	schema := query.Records
+	if searchTables {
+		schema = query.Tables
+	}
+
+	var q store.Query
+	if searchWhere != "" {
+		f, err := query.Compile(searchWhere, schema)
+		if err != nil {
+			return nil, false, err
+		}
+		q.Where, q.Args = "("+f.Where+")", f.Args
+	}
+	if len(names) > 0 {
+		var prefixes []string
+		for _, name := range names {
+			prefixes = append(prefixes, schema.Table+".name LIKE ? ESCAPE '\\'")
+			q.Args = append(q.Args, query.LikePrefix(name))
+		}
+		cond := "(" + strings.Join(prefixes, " OR ") + ")"
+		if q.Where != "" {
+			cond = q.Where + " AND " + cond
+		}
+		q.Where = cond
+	}
+
+	if q.OrderBy, err = schema.OrderBy(searchSort); err != nil {
+		return nil, false, err
+	}
+	if paged = !tagFiltered(); paged {
+		q.Limit, q.Offset = searchLimit, searchOffset
+	}
+
+	if searchTables {
+		tables, err := Store.FilterTables(q)
+		if err != nil {
+			return nil, false, err
+		}
+		for i := range tables {
+			results = append(results, &tables[i])
+		}
+		return results, paged, nil
+	}
+
+	records, err := Store.FilterRecords(q)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, r := range records {
+		results = append(results, r)
+	}
+	return results, paged, nil
+}
+
+// sortByName orders results by full name, for a stable output without --sort
+func sortByName(results []interface{}) {
+	sort.SliceStable(results, func(i, j int) bool { return resultName(results[i]) < resultName(results[j]) })
+}
+
+// page applies --offset and --limit to results gathered without paging
+func page[T any](items []T) []T {
+	if searchOffset >= len(items) {
+		return nil
+	}
+	items = items[searchOffset:]
+	if searchLimit > 0 && len(items) > searchLimit {
+		items = items[:searchLimit]
+	}
+	return items
+}
+
+// runTextSearch prints ranked full-text hits with the matched words highlighted
+func runTextSearch() error {
+	limit := 0
+	if searchLimit > 0 {
+		limit = searchOffset + searchLimit
+	}
+	hits, err := Store.SearchText(searchText, limit)
+	if err != nil {
+		return err
+	}
+	hits = page(hits)
+	if len(hits) == 0 {
+		fmt.Fprintf(os.Stderr, "no match: %s\n", searchText)
+		return nil
+	}
+
+	return writeSearchOutput(len(hits), func(w io.Writer, color bool) error {
 		if searchFormat == "json" {
-			enc := json.NewEncoder(os.Stdout)
-			enc.SetIndent("", "  ")
-			if err := enc.Encode(results); err != nil {
-				return err
-			}
-		} else {
-			// Default text output (simplified)
-			for _, res := range results {
-				fmt.Printf("%+v\n", res)
+			plain := strings.NewReplacer(store.HighlightStart, "[", store.HighlightEnd, "]")
+			for i := range hits {
+				hits[i].Snippet = plain.Replace(hits[i].Snippet)
 			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(hits)
 		}
 
+		p := painter(color)
+		mark := strings.NewReplacer(store.HighlightStart, "", store.HighlightEnd, "")
+		if color {
+			mark = strings.NewReplacer(store.HighlightStart, Bold+Yellow, store.HighlightEnd, Reset)
+		}
+		for _, h := range hits {
+			if searchMode == modeNameOnly {
+				fmt.Fprintln(w, h.Name)
+				continue
+			}
+			fmt.Fprintf(w, "%s %s\n", p(Cyan, h.Name), p(Gray, "("+h.Kind+")"))
+			fmt.Fprintf(w, "  %s\n", mark.Replace(h.Snippet))
+		}
 		return nil
-	},
+	})
 }
 
-func init() {
-	rootCmd.AddCommand(searchCmd)
+// tagFiltered reports whether any of --tag, --any-tag and --exclude-tag is set
+func tagFiltered() bool {
+	return len(searchTags) > 0 || len(searchAnyTags) > 0 || len(searchNoTags) > 0
+}
 
-	searchCmd.Flags().StringVar(&searchMode, "mode", "detail", "Display mode (detail, name-only, oneline)")
-	searchCmd.Flags().StringVar(&searchFormat, "format", "json", "Output format (json, text)")
-	searchCmd.Flags().StringVar(&searchOutputFile, "output-file", "", "Output file")
+// filterByTags keeps the results matching the --tag, --any-tag and --exclude-tag filters
+func filterByTags(results []interface{}) []interface{} {
+	if !tagFiltered() {
+		return results
+	}
+
+	var kept []interface{}
+	for _, res := range results {
+		if matchTags(itemTags(res)) {
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
+
+// matchTags reports whether a tag set satisfies all tag filters
+func matchTags(tags []string) bool {
+	has := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		has[tag] = true
+	}
+
+	for _, tag := range searchTags {
+		if !has[tag] {
+			return false
+		}
+	}
+	for _, tag := range searchNoTags {
+		if has[tag] {
+			return false
+		}
+	}
+	if len(searchAnyTags) == 0 {
+		return true
+	}
+	for _, tag := range searchAnyTags {
+		if has[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// itemTags returns the tags of a search result
+func itemTags(res interface{}) []string {
+	switch v := res.(type) {
+	case *model.Table:
+		return v.Tags
+	case *model.Record:
+		return v.Tags
+	case model.Record:
+		return v.Tags
+	}
+	return nil
 }
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"srdm/internal/model"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Display modes of the search command
+const (
+	modeDetail   = "detail"
+	modeNameOnly = "name-only"
+	modeOneline  = "oneline"
+)
+
+// writeSearchOutput runs render against stdout or, with --output-file, against that file
+// Colors are only used on stdout
+func writeSearchOutput(count int, render func(w io.Writer, color bool) error) error {
+	if searchOutputFile == "" {
+		return render(os.Stdout, true)
+	}
+
+	file, err := os.Create(searchOutputFile)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := render(file, false); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Printf("Wrote %d results to %s\n", count, searchOutputFile)
+	return nil
+}
+
+// renderResults writes search results in the selected --format and --mode
+func renderResults(w io.Writer, results []interface{}, color bool) error {
+	switch searchMode {
+	case modeDetail, modeNameOnly, modeOneline:
+	default:
+		return fmt.Errorf("unsupported mode: %s (use detail, name-only or oneline)", searchMode)
+	}
+
+	switch searchFormat {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if searchMode == modeNameOnly {
+			names := make([]string, len(results))
+			for i, res := range results {
+				names[i] = resultName(res)
+			}
+			return enc.Encode(names)
+		}
+		return enc.Encode(results)
+	case "text":
+		p := painter(color)
+		switch searchMode {
+		case modeNameOnly:
+			for _, res := range results {
+				fmt.Fprintln(w, resultName(res))
+			}
+			return nil
+		case modeOneline:
+			return renderOneline(w, results, p)
+		default:
+			return renderDetail(w, results, p)
+		}
+	default:
+		return fmt.Errorf("unsupported format: %s (use json or text)", searchFormat)
+	}
+}
+
+// renderOneline prints an aligned table with one row per result
+// Whole rows are colored so escape codes add the same width to every line
+func renderOneline(w io.Writer, results []interface{}, p func(string, string) string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, p(Cyan, "NAME\tKIND\tTYPE\tLABEL\tN\tMISS\tUNIQUE\tTAGS"))
+	for _, res := range results {
+		if t := asTable(res); t != nil {
+			fmt.Fprintln(tw, p(Purple, fmt.Sprintf("%s\ttable\t%s\t\t%d\t\t\t%s",
+				t.FullName(), t.Engine, len(t.Records), strings.Join(t.Tags, ","))))
+			continue
+		}
+		if r := asRecord(res); r != nil {
+			fmt.Fprintln(tw, p(Green, fmt.Sprintf("%s\trecord\t%s\t%s\t%d\t%d\t%d\t%s",
+				r.FullName(), r.Type, r.Label, r.Number, r.MissNumber, r.UniqueNumber, strings.Join(r.Tags, ","))))
+		}
+	}
+	return tw.Flush()
+}
+
+// renderDetail prints every field of each result as aligned label/value rows
+func renderDetail(w io.Writer, results []interface{}, p func(string, string) string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(label string, value any) {
+		if s, ok := value.(string); ok && s == "" {
+			return
+		}
+		fmt.Fprintf(tw, "  %s\t%v\n", p(Cyan, label+":"), value)
+	}
+
+	for i, res := range results {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		if t := asTable(res); t != nil {
+			fmt.Fprintf(tw, "%s %s\n", p(Purple, "Table"), p(Bold, t.FullName()))
+			row("Keys", t.Keys)
+			row("Path", t.Path)
+			row("Engine", t.Engine)
+			row("Source", t.Source)
+			row("Description", t.Description)
+			row("Script", joinTag(t.ScriptFile, t.ScriptTag))
+			row("Desc File", joinTag(t.DescFile, t.DescTag))
+			row("Log File", t.LogFile)
+			row("Tags", strings.Join(t.Tags, ", "))
+			row("Attributes", formatAttributes(t.Attributes))
+			row("Records", len(t.Records))
+			row("Created", formatTime(t.CreateAt))
+			row("Modified", formatTime(t.ModifyAt))
+			continue
+		}
+		if r := asRecord(res); r != nil {
+			fmt.Fprintf(tw, "%s %s\n", p(Green, "Record"), p(Bold, r.FullName()))
+			row("Type", r.Type)
+			row("Label", r.Label)
+			row("Source", r.Source)
+			row("Description", r.Description)
+			row("Stats", fmt.Sprintf("N=%d, Miss=%d, Unique=%d", r.Number, r.MissNumber, r.UniqueNumber))
+			row("Script", joinTag(r.ScriptFile, r.ScriptTag))
+			row("Desc File", joinTag(r.DescFile, r.DescTag))
+			row("Log File", r.LogFile)
+			row("Tags", strings.Join(r.Tags, ", "))
+			row("Attributes", formatAttributes(r.Attributes))
+			row("Created", formatTime(r.CreateAt))
+			row("Modified", formatTime(r.ModifyAt))
+		}
+	}
+	return tw.Flush()
+}
+
+// painter returns Colorize, or a no-op when color is disabled
+func painter(color bool) func(string, string) string {
+	if color {
+		return Colorize
+	}
+	return func(_ string, text string) string { return text }
+}
+
+// asTable returns the table held by a search result, or nil
+func asTable(res interface{}) *model.Table {
+	if t, ok := res.(*model.Table); ok {
+		return t
+	}
+	return nil
+}
+
+// asRecord returns the record held by a search result, or nil
+func asRecord(res interface{}) *model.Record {
+	switch v := res.(type) {
+	case *model.Record:
+		return v
+	case model.Record:
+		return &v
+	}
+	return nil
+}
+
+// resultName returns the full name of a search result
+func resultName(res interface{}) string {
+	if t := asTable(res); t != nil {
+		return t.FullName()
+	}
+	if r := asRecord(res); r != nil {
+		return r.FullName()
+	}
+	return fmt.Sprint(res)
+}
+
+// joinTag formats a file with its version tag as "file@tag"
+func joinTag(file, tag string) string {
+	if file == "" || tag == "" {
+		return file
+	}
+	return file + "@" + tag
+}
+
+// formatAttributes renders attributes as "key=value" pairs sorted by key
+func formatAttributes(attrs map[string]string) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + attrs[k]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// formatTime renders a timestamp, leaving zero times empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -3,9 +3,13 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"srdm/internal/model"
+	"strings"
 	"testing"
 )
 
@@ -69,3 +73,114 @@ func TestSearchRecord(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchOutputModes(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		searchMode, searchFormat, searchOutputFile = "detail", "json", ""
+	}()
+
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "rec1", Type: "int", Label: "first"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "rec2", Type: "string", Label: "second"})
+
+	out := filepath.Join(t.TempDir(), "names.txt")
+	rootCmd.SetArgs([]string{"search", "db:t:rec", "--format", "text", "--mode", "name-only", "--output-file", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Output file not written: %v", err)
+	}
+	if got := string(data); got != "db:t:rec1\ndb:t:rec2\n" {
+		t.Errorf("Unexpected name-only output: %q", got)
+	}
+
+	out = filepath.Join(t.TempDir(), "table.txt")
+	rootCmd.SetArgs([]string{"search", "db:t:rec", "--format", "text", "--mode", "oneline", "--output-file", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	data, _ = os.ReadFile(out)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 rows, got %q", data)
+	}
+	if strings.Contains(string(data), "\033[") {
+		t.Errorf("Output file should not contain color codes: %q", data)
+	}
+	col := strings.Index(lines[0], "LABEL")
+	if col < 0 || !strings.HasPrefix(lines[1][col:], "first") || !strings.HasPrefix(lines[2][col:], "second") {
+		t.Errorf("Columns not aligned:\n%s", data)
+	}
+
+	rootCmd.SetArgs([]string{"search", "db:t:rec1", "--mode", "bogus"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestSearchNamePrefixesMatchLiterally(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		searchMode, searchFormat, searchOutputFile, searchTables = "detail", "json", "", false
+	}()
+
+	out := filepath.Join(t.TempDir(), "names.txt")
+	rootCmd.SetArgs([]string{"search", "--tables", "db:a_b", "db:100%", "--format", "text", "--mode", "name-only", "--output-file", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	q := mockStore.LastQuery
+	if !strings.Contains(q.Where, "ESCAPE") || !reflect.DeepEqual(q.Args, []any{`db:a\_b%`, `db:100\%%`}) {
+		t.Errorf("Name prefixes not escaped: %s %v", q.Where, q.Args)
+	}
+}
+
+func TestSearchPaging(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		searchMode, searchFormat, searchOutputFile = "detail", "json", ""
+		searchWhere, searchLimit, searchOffset, searchTags = "", 0, 0, nil
+	}()
+
+	for i, tags := range [][]string{{"raw"}, nil, {"raw"}, {"raw"}} {
+		mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: fmt.Sprintf("rec%d", i+1), Tags: tags})
+	}
+
+	tests := []struct {
+		args []string
+		want int
+	}{
+		// Plain names page too
+		{[]string{"db:t:rec", "--limit", "2", "--offset", "1"}, 2},
+		{[]string{"db:t:rec", "--offset", "3"}, 1},
+		// Tag filters apply before paging, so the page stays full
+		{[]string{"--where", "type=int", "--tag", "raw", "--limit", "2"}, 2},
+		{[]string{"--where", "type=int", "--tag", "raw", "--offset", "2"}, 1},
+	}
+	for _, tt := range tests {
+		searchWhere, searchLimit, searchOffset, searchTags = "", 0, 0, nil
+		out := filepath.Join(t.TempDir(), "names.txt")
+		rootCmd.SetArgs(append([]string{"search", "--format", "text", "--mode", "name-only", "--output-file", out}, tt.args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v: Execute failed: %v", tt.args, err)
+		}
+		data, _ := os.ReadFile(out)
+		if got := len(strings.Fields(string(data))); got != tt.want {
+			t.Errorf("%v: got %d results, want %d: %q", tt.args, got, tt.want, data)
+		}
+	}
+
+	searchLimit, searchOffset = 0, 0
+	rootCmd.SetArgs([]string{"search", "db:t:rec", "--offset", "-1"})
+	if err := rootCmd.Execute(); exitCode(err) != ExitUsage {
+		t.Errorf("Expected a usage error for a negative offset, got %v", err)
+	}
+}
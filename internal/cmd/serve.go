@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"srdm/internal/server"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var serveAddr string
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the repository as a JSON REST API",
+	Long: `Serve the tables and records of the repository over HTTP as JSON.
+
+  GET/POST           /api/tables, /api/records
+  GET/PATCH/DELETE   /api/tables/{db:table}, /api/records/{db:table:record}
+  GET                /api/search?q=..., /api/stats, /api/openapi.json
+
+Lists take the same --where and --sort expressions as 'srdm search' as the where
+and sort parameters. Writes are serialized, and other srdm processes may use the
+repository meanwhile. The server has no authentication: keep it on localhost.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		srv := &http.Server{
+			Addr:              serveAddr,
+			Handler:           server.New(Store, slog.Default()),
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		errc := make(chan error, 1)
+		go func() { errc <- srv.ListenAndServe() }()
+		fmt.Fprintf(os.Stderr, "Serving %s on http://%s (OpenAPI at /api/openapi.json)\n", Store.GetPath(), serveAddr)
+
+		select {
+		case err := <-errc:
+			return fmt.Errorf("failed to serve: %w", err)
+		case <-ctx.Done():
+		}
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to shut down: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Address to listen on")
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// tagCmd groups the tag management subcommands
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage tags of tables and records",
+	Long: `Attach, detach and list tags. A table or record can carry any number of tags,
+so one dataset can be "cleaned", "paper-2025" and "confidential" at the same time.`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add [name] [tags...]",
+	Short: "Attach tags to a table or record",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := Store.AddTags(name, args[1:]...); err != nil {
+			return err
+		}
+		fmt.Printf("Tagged %s: %v\n", name, args[1:])
+		return nil
+	},
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove [name] [tags...]",
+	Short: "Detach tags from a table or record",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := Store.RemoveTags(name, args[1:]...); err != nil {
+			return err
+		}
+		fmt.Printf("Untagged %s: %v\n", name, args[1:])
+		return nil
+	},
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list [name]",
+	Short: "List tags of a table or record, or all tags in use",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		tags, err := Store.ListTags(name)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			fmt.Println(tag)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestSearchByTags(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		searchTags, searchAnyTags, searchNoTags = nil, nil, nil
+	}()
+
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a", Tags: []string{"cleaned", "paper-2025"}})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "b", Tags: []string{"cleaned", "confidential"}})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "c", Tags: []string{"raw"}})
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd.SetArgs([]string{
+		"search",
+		"--tag", "cleaned",
+		"--exclude-tag", "confidential",
+		"--format", "json",
+	})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+
+	w.Close()
+	os.Stdout = oldStdout
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	var results []model.Record
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("Failed to parse JSON: %v (%s)", err, buf.String())
+	}
+	if len(results) != 1 || results[0].Name != "a" {
+		t.Errorf("Expected only record a, got %+v", results)
+	}
+}
+
+func TestTagAdd(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() { Store = nil }()
+
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t"})
+
+	rootCmd.SetArgs([]string{"tag", "add", "db:t", "cleaned", "paper-2025"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Tag add failed: %v", err)
+	}
+
+	tbl, _ := mockStore.GetTable("db:t")
+	if len(tbl.Tags) != 2 {
+		t.Errorf("Expected 2 tags, got %v", tbl.Tags)
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashOlderThan string
+	trashPurgeAll  bool
+	restoreID      int64
+)
+
+// trashCmd groups the trash subcommands
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List or purge deleted tables and records",
+	Long: `Deleted tables and records are kept in the trash, hidden from every other command,
+until they are restored with 'srdm restore' or purged.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted tables and records, most recent first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := Store.Trash()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			fmt.Fprintln(os.Stderr, "Trash is empty.")
+			return nil
+		}
+
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tKIND\tRECORDS\tUSER\tDELETED AT")
+		for _, it := range items {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n",
+				it.ID, it.Name, it.Kind, it.Records, it.User, it.DeletedAt.Format(time.RFC3339))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		header, rows, _ := strings.Cut(buf.String(), "\n")
+		fmt.Println(Colorize(Cyan, header))
+		fmt.Print(rows)
+		return nil
+	},
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove deleted items",
+	Long: `Permanently remove the items deleted more than --older-than ago, e.g. 30d, 2w or 12h.
+Use --all to empty the whole trash. Purged items cannot be restored.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if (trashOlderThan == "") == !trashPurgeAll {
+			return fmt.Errorf("use either --older-than or --all")
+		}
+		before := time.Now()
+		if trashOlderThan != "" {
+			age, err := parseAge(trashOlderThan)
+			if err != nil {
+				return err
+			}
+			before = before.Add(-age)
+		}
+
+		n, err := Store.PurgeTrash(before)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Purged %d items from the trash\n", n)
+		return nil
+	},
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore [name]",
+	Short: "Restore a deleted table or record from the trash",
+	Long: `Restore a deleted table or record. A table comes back with the records deleted
+together with it. Tags, attributes, checksums and lineage are restored as well.
+If the trash holds several items of that name, the most recent one is restored
+unless --id picks another (see 'srdm trash list').`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := Store.Restore(args[0], restoreID); err != nil {
+			return err
+		}
+		fmt.Printf("Restored: %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(restoreCmd)
+	trashCmd.AddCommand(trashListCmd, trashPurgeCmd)
+
+	trashPurgeCmd.Flags().StringVar(&trashOlderThan, "older-than", "", "Only purge items deleted longer ago than this (e.g. 30d, 2w, 12h)")
+	trashPurgeCmd.Flags().BoolVar(&trashPurgeAll, "all", false, "Purge every item in the trash")
+	restoreCmd.Flags().Int64Var(&restoreID, "id", 0, "Trash entry to restore (default: the most recent of that name)")
+}
+
+// parseAge parses a duration with optional day (d) and week (w) units
+func parseAge(s string) (time.Duration, error) {
+	for unit, size := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, unit); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * size, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+	"time"
+)
+
+func TestTrashRestore(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		deleteForce, restoreID = false, 0
+		trashOlderThan, trashPurgeAll = "", false
+	}()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t"})
+	mockStore.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a"})
+
+	rootCmd.SetArgs([]string{"delete", "--force", "db:t"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"trash", "list"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("trash list failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"restore", "db:t"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if _, ok := mockStore.Records["db:t:a"]; !ok {
+		t.Error("Record was not restored with its table")
+	}
+
+	rootCmd.SetArgs([]string{"trash", "purge"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected purge without --older-than or --all to fail")
+	}
+	rootCmd.SetArgs([]string{"delete", "db:t:a"})
+	rootCmd.Execute()
+	rootCmd.SetArgs([]string{"trash", "purge", "--older-than", "30d"})
+	if err := rootCmd.Execute(); err != nil || len(mockStore.Trashed) != 1 {
+		t.Errorf("Recent item should survive purge --older-than (%v)", err)
+	}
+	rootCmd.SetArgs([]string{"trash", "purge", "--all"})
+	trashOlderThan = ""
+	if err := rootCmd.Execute(); err != nil || len(mockStore.Trashed) != 0 {
+		t.Errorf("purge --all left %d items (%v)", len(mockStore.Trashed), err)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	}
+	for in, want := range tests {
+		if got, err := parseAge(in); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseAge("soon"); err == nil {
+		t.Error("Expected error for an invalid age")
+	}
+}
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"strings"
+	"srdm/internal/store"
 
 	"github.com/spf13/cobra"
 )
@@ -24,6 +24,8 @@ var (
 	updateNumber       int
 	updateMissNumber   int
 	updateUniqueNumber int
+	updateTags         []string
+	updateAttrs        []string
 )
 
 var updateCmd = &cobra.Command{
@@ -35,14 +37,14 @@ var updateCmd = &cobra.Command{
 			return fmt.Errorf("--name is required")
 		}
 
-		parts := strings.Split(updateName, ":")
+		parts, err := store.SplitName(updateName)
+		if err != nil {
+			return err
+		}
 		if len(parts) == 2 {
 			return updateTable(parts[0], parts[1])
-		} else if len(parts) == 3 {
-			return updateRecord(parts[0], parts[1], parts[2])
-		} else {
-			return fmt.Errorf("invalid name format")
 		}
+		return updateRecord(parts[0], parts[1], parts[2])
 	},
 }
 
@@ -59,6 +61,8 @@ func init() {
 	updateCmd.Flags().StringVar(&updateDescFile, "desc_file", "", "Data analysis file")
 	updateCmd.Flags().StringVar(&updateDescTag, "desc_tag", "", "Analysis file version tag")
 	updateCmd.Flags().StringVar(&updateLogFile, "log_file", "", "Data usage log file")
+	updateCmd.Flags().StringSliceVar(&updateTags, "tag", nil, "Tag to add (repeatable or comma-separated)")
+	updateCmd.Flags().StringArrayVar(&updateAttrs, "attr", nil, "Set a custom attribute as key=value, or remove it with key= (repeatable)")
 
 	updateCmd.Flags().StringVar(&updateType, "type", "", "Record type")
 	updateCmd.Flags().StringVar(&updateLabel, "label", "", "Data label")
@@ -73,9 +77,6 @@ func updateTable(database, name string) error {
 	if err != nil {
 		return err
 	}
-	if t == nil {
-		return fmt.Errorf("table not found: %s", fullName)
-	}
 
 	// Update fields if provided (not empty or 0)
 	if updateKeys != "" {
@@ -108,8 +109,23 @@ func updateTable(database, name string) error {
 	if updateLogFile != "" {
 		t.LogFile = updateLogFile
 	}
+	if len(updateTags) > 0 {
+		t.Tags = store.NormalizeTags(append(t.Tags, updateTags...))
+	}
+	if t.Attributes, err = mergeAttributes(t.Attributes, updateAttrs); err != nil {
+		return err
+	}
 
-	if err := Store.UpdateTable(t); err != nil {
+	if err := Store.WithTx(func(repo store.Repository) error {
+		if err := repo.UpdateTable(t); err != nil {
+			return err
+		}
+		// A new path is a new file: record its checksum
+		if updatePath == "" {
+			return nil
+		}
+		return trackFiles(repo, t.FullName(), tableFiles(t))
+	}); err != nil {
 		return err
 	}
 	fmt.Printf("Updated table: %s\n", t.FullName())
@@ -122,9 +138,6 @@ func updateRecord(database, table, name string) error {
 	if err != nil {
 		return err
 	}
-	if r == nil {
-		return fmt.Errorf("record not found: %s", fullName)
-	}
 
 	if updateType != "" {
 		r.Type = updateType
@@ -162,8 +175,22 @@ func updateRecord(database, table, name string) error {
 	if updateLogFile != "" {
 		r.LogFile = updateLogFile
 	}
+	if len(updateTags) > 0 {
+		r.Tags = store.NormalizeTags(append(r.Tags, updateTags...))
+	}
+	if r.Attributes, err = mergeAttributes(r.Attributes, updateAttrs); err != nil {
+		return err
+	}
 
-	if err := Store.UpdateRecord(r); err != nil {
+	if err := Store.WithTx(func(repo store.Repository) error {
+		if err := repo.UpdateRecord(r); err != nil {
+			return err
+		}
+		if updateSource == "" {
+			return nil
+		}
+		return trackFiles(repo, r.FullName(), recordFiles(r))
+	}); err != nil {
 		return err
 	}
 	fmt.Printf("Updated record: %s\n", r.FullName())
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestUpdateAttributes(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		insertAttrs, updateAttrs = nil, nil
+		insertName, updateName = "", ""
+	}()
+	mockStore.InsertTable(&model.Table{Database: "db", Name: "t"})
+
+	rootCmd.SetArgs([]string{"insert", "--name", "db:t:a", "--attr", "license=CC-BY", "--attr", "pi=Ann Lee"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	if attrs := mockStore.Records["db:t:a"].Attributes; attrs["license"] != "CC-BY" || attrs["pi"] != "Ann Lee" {
+		t.Errorf("Unexpected attributes after insert: %v", attrs)
+	}
+
+	// Setting a key overrides it, an empty value removes it, others are kept
+	rootCmd.SetArgs([]string{"update", "--name", "db:t:a", "--attr", "license=CC0", "--attr", "pi="})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	attrs := mockStore.Records["db:t:a"].Attributes
+	if len(attrs) != 1 || attrs["license"] != "CC0" {
+		t.Errorf("Unexpected attributes after update: %v", attrs)
+	}
+
+	rootCmd.SetArgs([]string{"update", "--name", "db:t:a", "--attr", "novalue"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for an attribute without =")
+	}
+}
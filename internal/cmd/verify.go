@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"srdm/internal/checksum"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyUpdate bool
+	verifyQuiet  bool
+)
+
+// errDrift reports registered files that changed or disappeared
+var errDrift = errors.New("files drifted")
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify [name|glob]",
+	Short: "Check registered data files for changes",
+	Long: `Re-hash the files referenced by tables (path) and records (source) and compare them
+with the size and SHA-256 recorded when they were registered.
+
+A name checks that item and everything below it, so 'verify survey' checks a whole
+database; a glob such as 'survey:wave*' selects items as in 'srdm get'.
+
+Each file is reported as unchanged, modified or missing. When any file drifted the
+exit code is 9, apart from the 1 of other failures, so verify can run from cron.
+Use --update to accept the current content of modified files as the new baseline.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := "*"
+		if len(args) > 0 {
+			pattern = args[0]
+		}
+
+		sums, err := Store.Checksums(pattern)
+		if err != nil {
+			return err
+		}
+		if len(sums) == 0 {
+			fmt.Fprintln(os.Stderr, "No registered files to verify.")
+			return nil
+		}
+
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STATUS\tNAME\tFIELD\tPATH")
+		var statuses []string
+		counts := map[string]int{}
+		for _, c := range sums {
+			status, current, err := checksum.Verify(c)
+			if err != nil {
+				return err
+			}
+			counts[status]++
+			if status == checksum.StatusModified && verifyUpdate {
+				if err := Store.SaveChecksum(current); err != nil {
+					return err
+				}
+			}
+			if verifyQuiet && status == checksum.StatusUnchanged {
+				continue
+			}
+			statuses = append(statuses, status)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status, c.Name, c.Field, c.Path)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		// Color the aligned output; codes inside cells would skew the columns
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		fmt.Println(Colorize(Cyan, lines[0]))
+		for i, line := range lines[1:] {
+			status := statuses[i]
+			fmt.Println(Colorize(statusColor(status), status) + line[len(status):])
+		}
+
+		// Modified files accepted with --update no longer count as drift
+		drifted := counts[checksum.StatusMissing]
+		if !verifyUpdate {
+			drifted += counts[checksum.StatusModified]
+		}
+		fmt.Printf("%d unchanged, %d modified, %d missing\n",
+			counts[checksum.StatusUnchanged], counts[checksum.StatusModified], counts[checksum.StatusMissing])
+		if drifted > 0 {
+			return fmt.Errorf("%w: %d of %d", errDrift, drifted, len(sums))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().BoolVar(&verifyUpdate, "update", false, "Record the current state of modified files as the new baseline")
+	verifyCmd.Flags().BoolVarP(&verifyQuiet, "quiet", "q", false, "Only list files that drifted")
+}
+
+// statusColor maps a verification status to its display color
+func statusColor(status string) string {
+	switch status {
+	case checksum.StatusUnchanged:
+		return Green
+	case checksum.StatusModified:
+		return Yellow
+	}
+	return Red
+}
+
+// trackFiles records the checksum of each field's file
+// Fields whose value is not an existing file lose any previous checksum,
+// since free-text sources such as "World Bank" are not files
+func trackFiles(repo store.Repository, name string, files map[string]string) error {
+	for field, path := range files {
+		if !checksum.IsFile(path) {
+			if err := repo.RemoveChecksum(name, field); err != nil {
+				return err
+			}
+			continue
+		}
+		c, err := checksum.Compute(name, field, path)
+		if err != nil {
+			return err
+		}
+		if err := repo.SaveChecksum(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableFiles returns the checksummed files of a table
+func tableFiles(t *model.Table) map[string]string {
+	return map[string]string{model.FileFieldPath: t.Path}
+}
+
+// recordFiles returns the checksummed files of a record
+func recordFiles(r *model.Record) map[string]string {
+	return map[string]string{model.FileFieldSource: r.Source}
+}
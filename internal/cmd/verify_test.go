@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestVerifyDrift(t *testing.T) {
+	mockStore := NewMockRepository()
+	Store = mockStore
+	defer func() {
+		Store = nil
+		insertName, insertKeys, insertPath = "", "", ""
+		verifyUpdate = false
+	}()
+
+	path := filepath.Join(t.TempDir(), "data.sqlite")
+	os.WriteFile(path, []byte("v1"), 0644)
+
+	rootCmd.SetArgs([]string{"insert", "--name", "db:t", "--keys", "id", "--data-path", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if c, ok := mockStore.Sums["db:t|"+model.FileFieldPath]; !ok || c.Size != 2 {
+		t.Fatalf("Checksum not recorded on insert: %+v", mockStore.Sums)
+	}
+
+	rootCmd.SetArgs([]string{"verify"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Verify of unchanged file failed: %v", err)
+	}
+
+	os.WriteFile(path, []byte("v2 longer"), 0644)
+	rootCmd.SetArgs([]string{"verify", "d?:*"})
+	if err := rootCmd.Execute(); exitCode(err) != ExitDrift {
+		t.Errorf("Expected drift exit code for modified file, got %v", err)
+	}
+	rootCmd.SetArgs([]string{"verify", "other"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Verify of another database failed: %v", err)
+	}
+
+	// Accept the new content, then verify passes again
+	rootCmd.SetArgs([]string{"verify", "--update"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Verify --update failed: %v", err)
+	}
+	verifyUpdate = false
+	rootCmd.SetArgs([]string{"verify"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Verify after update failed: %v", err)
+	}
+
+	os.Remove(path)
+	rootCmd.SetArgs([]string{"verify"})
+	if err := rootCmd.Execute(); exitCode(err) != ExitDrift {
+		t.Errorf("Expected drift exit code for missing file, got %v", err)
+	}
+}
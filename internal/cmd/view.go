@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"srdm/internal/store"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -15,7 +18,10 @@ var viewCmd = &cobra.Command{
 		name := args[0]
 
 		t, err := Store.GetTable(name)
-		if err == nil && t != nil {
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+		if err == nil {
 			fmt.Printf("Table: %s\n", t.FullName())
 			fmt.Printf("  Database:    %s\n", t.Database)
 			fmt.Printf("  Name:        %s\n", t.Name)
@@ -24,6 +30,8 @@ var viewCmd = &cobra.Command{
 			fmt.Printf("  Engine:      %s\n", t.Engine)
 			fmt.Printf("  Description: %s\n", t.Description)
 			fmt.Printf("  Source:      %s\n", t.Source)
+			fmt.Printf("  Tags:        %s\n", strings.Join(t.Tags, ", "))
+			fmt.Printf("  Attributes:  %s\n", formatAttributes(t.Attributes))
 			fmt.Printf("  CreateAt:    %s\n", t.CreateAt)
 			fmt.Printf("  ModifyAt:    %s\n", t.ModifyAt)
 			fmt.Printf("  Records:     %d\n", len(t.Records))
@@ -31,22 +39,23 @@ var viewCmd = &cobra.Command{
 		}
 
 		r, err := Store.GetRecord(name)
-		if err == nil && r != nil {
-			fmt.Printf("Record: %s\n", r.FullName())
-			fmt.Printf("  Database:    %s\n", r.Database)
-			fmt.Printf("  Table:       %s\n", r.Table)
-			fmt.Printf("  Name:        %s\n", r.Name)
-			fmt.Printf("  Type:        %s\n", r.Type)
-			fmt.Printf("  Label:       %s\n", r.Label)
-			fmt.Printf("  Source:      %s\n", r.Source)
-			fmt.Printf("  Description: %s\n", r.Description)
-			fmt.Printf("  Stats:       N=%d, Miss=%d, Unique=%d\n", r.Number, r.MissNumber, r.UniqueNumber)
-			fmt.Printf("  CreateAt:    %s\n", r.CreateAt)
-			fmt.Printf("  ModifyAt:    %s\n", r.ModifyAt)
-			return nil
+		if err != nil {
+			return err
 		}
-
-		return fmt.Errorf("not found: %s", name)
+		fmt.Printf("Record: %s\n", r.FullName())
+		fmt.Printf("  Database:    %s\n", r.Database)
+		fmt.Printf("  Table:       %s\n", r.Table)
+		fmt.Printf("  Name:        %s\n", r.Name)
+		fmt.Printf("  Type:        %s\n", r.Type)
+		fmt.Printf("  Label:       %s\n", r.Label)
+		fmt.Printf("  Source:      %s\n", r.Source)
+		fmt.Printf("  Description: %s\n", r.Description)
+		fmt.Printf("  Tags:        %s\n", strings.Join(r.Tags, ", "))
+		fmt.Printf("  Attributes:  %s\n", formatAttributes(r.Attributes))
+		fmt.Printf("  Stats:       N=%d, Miss=%d, Unique=%d\n", r.Number, r.MissNumber, r.UniqueNumber)
+		fmt.Printf("  CreateAt:    %s\n", r.CreateAt)
+		fmt.Printf("  ModifyAt:    %s\n", r.ModifyAt)
+		return nil
 	},
 }
 
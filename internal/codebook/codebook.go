@@ -0,0 +1,175 @@
+// Package codebook renders the data dictionary of a table with text/template
+//
+// Built-in templates exist for each format in Formats. A user template gets
+// the same Data and helper functions:
+//
+//	{{.Title}}              document title
+//	{{.Table}}              the model.Table, e.g. {{.Table.FullName}}, {{.Table.Keys}}
+//	{{.Variables}}          the table's records, one per variable
+//	{{.Generated}}          time of rendering
+//
+//	{{md .Label}}           escape for Markdown, table cells included
+//	{{html .Label}}         escape for HTML
+//	{{latex .Label}}        escape for LaTeX
+//	{{pct .MissNumber .Number}}  share as a percentage, "" if the total is 0
+//	{{note .}}              description followed by the attributes of a record
+//	{{attrs .Attributes}}   attributes as "key=value; ..."
+//	{{date .Generated}}     date as YYYY-MM-DD
+//	{{join .Tags ", "}}     strings.Join
+package codebook
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"srdm/internal/model"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var builtin embed.FS
+
+// Data is what a codebook template is executed with
+type Data struct {
+	Title     string
+	Table     *model.Table
+	Variables []model.Record
+	Generated time.Time
+}
+
+// New returns the data of a table loaded with its records
+func New(title string, t *model.Table) *Data {
+	if title == "" {
+		title = "Codebook: " + t.FullName()
+	}
+	return &Data{Title: title, Table: t, Variables: t.Records, Generated: time.Now()}
+}
+
+// formats maps the built-in formats to the output file extensions that select them
+var formats = map[string][]string{
+	"markdown":             {".md", ".markdown"},
+	"html":                 {".html", ".htm"},
+	"latex":                {".tex"},
+	"docx-compatible-html": {".doc"},
+}
+
+// Formats returns the built-in formats, sorted
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for f := range formats {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FormatForFile returns the built-in format for the extension of path, or ""
+func FormatForFile(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for f, exts := range formats {
+		for _, e := range exts {
+			if e == ext {
+				return f
+			}
+		}
+	}
+	return ""
+}
+
+// Source returns the text of a built-in template, a starting point for custom ones
+func Source(format string) (string, error) {
+	if _, ok := formats[format]; !ok {
+		return "", fmt.Errorf("unsupported codebook format %q (use %s)", format, strings.Join(Formats(), ", "))
+	}
+	data, err := builtin.ReadFile("templates/" + format + ".tmpl")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Builtin returns the parsed built-in template of a format
+func Builtin(format string) (*template.Template, error) {
+	text, err := Source(format)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(format, text)
+}
+
+// Parse parses a template with the codebook helper functions
+func Parse(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid codebook template: %w", err)
+	}
+	return t, nil
+}
+
+// Render executes a template with the codebook data
+func Render(w io.Writer, t *template.Template, d *Data) error {
+	if err := t.Execute(w, d); err != nil {
+		return fmt.Errorf("failed to render codebook: %w", err)
+	}
+	return nil
+}
+
+var funcs = template.FuncMap{
+	"md":    markdown,
+	"latex": latex,
+	"pct":   pct,
+	"note":  note,
+	"attrs": attrs,
+	"join":  strings.Join,
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+}
+
+// markdown escapes Markdown markup and keeps the text on one line, for table cells
+func markdown(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "<", "&lt;", "|", `\|`).Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// latex escapes the characters LaTeX treats specially
+var latex = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`,
+	"_", `\_`, "{", `\{`, "}", `\}`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+).Replace
+
+// pct formats part as a percentage of total with one decimal
+func pct(part, total int) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
+
+// note returns the description of a record followed by its attributes
+func note(r model.Record) string {
+	parts := []string{}
+	if d := strings.TrimSpace(r.Description); d != "" {
+		parts = append(parts, d)
+	}
+	if a := attrs(r.Attributes); a != "" {
+		parts = append(parts, a)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// attrs formats attributes as sorted key=value pairs
+func attrs(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + m[k]
+	}
+	return strings.Join(pairs, "; ")
+}
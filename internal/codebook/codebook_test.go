@@ -0,0 +1,77 @@
+package codebook
+
+import (
+	"bytes"
+	"srdm/internal/model"
+	"strings"
+	"testing"
+)
+
+func testTable() *model.Table {
+	return &model.Table{
+		Database: "proj", Name: "survey", Keys: "id", Description: "Household survey",
+		Records: []model.Record{
+			{Database: "proj", Table: "survey", Name: "age_years", Type: "int", Label: "Age <years>",
+				Description: "50% & more", Number: 200, MissNumber: 5, UniqueNumber: 80,
+				Attributes: map[string]string{"unit": "years"}},
+			{Database: "proj", Table: "survey", Name: "notes", Type: "string"},
+		},
+	}
+}
+
+func TestBuiltinFormats(t *testing.T) {
+	tests := map[string][]string{
+		"markdown":             {"# Codebook: proj:survey", `| age\_years | int | Age &lt;years> | 50% & more; unit=years | 200 | 5 (2.5%) | 80 |`, "| notes | string |  |  | 0 | 0 | 0 |"},
+		"html":                 {"<title>Codebook: proj:survey</title>", "<td>Age &lt;years&gt;</td>", "<td>50% &amp; more; unit=years</td>"},
+		"latex":                {`\title{Codebook: proj:survey}`, `\texttt{age\_years} & int`, `50\% \& more`, `5 (2.5\%)`},
+		"docx-compatible-html": {`<meta name="ProgId" content="Word.Document">`, "<td>age_years</td>"},
+	}
+	for format, want := range tests {
+		tmpl, err := Builtin(format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		var buf bytes.Buffer
+		if err := Render(&buf, tmpl, New("", testTable())); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		for _, w := range want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("%s output missing %q:\n%s", format, w, buf.String())
+			}
+		}
+	}
+	if _, err := Builtin("pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestUserTemplate(t *testing.T) {
+	tmpl, err := Parse("custom", `{{.Title}}{{range .Variables}};{{.Name}}={{pct .MissNumber .Number}}{{end}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Render(&buf, tmpl, New("Survey", testTable())); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "Survey;age_years=2.5%;notes=" {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := Parse("bad", "{{.Title"); err == nil {
+		t.Error("Expected a parse error")
+	}
+	tmpl, _ = Parse("missing", "{{.Nope}}")
+	if err := Render(&buf, tmpl, New("", testTable())); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestFormatForFile(t *testing.T) {
+	for path, want := range map[string]string{"a.md": "markdown", "a.HTML": "html", "a.tex": "latex", "a.doc": "docx-compatible-html", "a.txt": ""} {
+		if got := FormatForFile(path); got != want {
+			t.Errorf("FormatForFile(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+// Package datapackage converts tables and records to and from Frictionless
+// Data Package descriptors (datapackage.json)
+//
+// A table becomes a tabular data resource and its records the fields of the
+// resource schema, with the table keys as primary key. Metadata without a
+// place in the Data Package specification (tags, attributes, scripts and the
+// record statistics) is kept in an "srdm" object on the resource or field,
+// which other tools ignore, so an exported package imports back unchanged.
+package datapackage
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"srdm/internal/model"
+	"strings"
+	"time"
+)
+
+// Profiles of the descriptors written by FromTables
+const (
+	PackageProfile  = "tabular-data-package"
+	ResourceProfile = "tabular-data-resource"
+)
+
+// Package is a Data Package descriptor
+type Package struct {
+	Profile   string     `json:"profile,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	Title     string     `json:"title,omitempty"`
+	Created   string     `json:"created,omitempty"`
+	Keywords  []string   `json:"keywords,omitempty"`
+	Resources []Resource `json:"resources"`
+}
+
+// Resource describes one data file of a package
+type Resource struct {
+	Profile     string     `json:"profile,omitempty"`
+	Name        string     `json:"name"`
+	Path        stringList `json:"path,omitempty"`
+	Format      string     `json:"format,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Sources     []Source   `json:"sources,omitempty"`
+	Schema      *Schema    `json:"schema,omitempty"`
+	SRDM        *Extra     `json:"srdm,omitempty"`
+}
+
+// Source is where the data of a resource comes from
+type Source struct {
+	Title string `json:"title,omitempty"`
+	Path  string `json:"path,omitempty"`
+}
+
+// Schema is a Table Schema
+type Schema struct {
+	Fields     []Field    `json:"fields"`
+	PrimaryKey stringList `json:"primaryKey,omitempty"`
+}
+
+// Field is one column of a Table Schema
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	SRDM        *Extra `json:"srdm,omitempty"`
+}
+
+// Extra holds the srdm metadata of a resource or field
+type Extra struct {
+	Name         string            `json:"name,omitempty"` // Full name of the table or record
+	Type         string            `json:"type,omitempty"` // Record type, when the field type does not map back to it
+	Engine       string            `json:"engine,omitempty"`
+	Source       string            `json:"source,omitempty"`
+	Number       int               `json:"number,omitempty"`
+	MissNumber   int               `json:"missNumber,omitempty"`
+	UniqueNumber int               `json:"uniqueNumber,omitempty"`
+	ScriptFile   string            `json:"script_file,omitempty"`
+	ScriptTag    string            `json:"script_tag,omitempty"`
+	DescFile     string            `json:"desc_file,omitempty"`
+	DescTag      string            `json:"desc_tag,omitempty"`
+	LogFile      string            `json:"log_file,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// stringList is a list that may be written as a single string, like path and primaryKey
+type stringList []string
+
+// MarshalJSON writes a single value as a plain string
+func (l stringList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]string(l))
+}
+
+// UnmarshalJSON accepts a string or a list of strings
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("expected a string or a list of strings")
+	}
+	*l = list
+	return nil
+}
+
+// fieldTypes maps record types to Table Schema types; others become "any"
+var fieldTypes = map[string]string{
+	"int":      "integer",
+	"integer":  "integer",
+	"float":    "number",
+	"number":   "number",
+	"double":   "number",
+	"date":     "date",
+	"datetime": "datetime",
+	"time":     "time",
+	"bool":     "boolean",
+	"boolean":  "boolean",
+	"string":   "string",
+	"text":     "string",
+}
+
+// recordTypes maps Table Schema types back to record types; others are kept as is
+var recordTypes = map[string]string{
+	"integer": "int",
+	"number":  "float",
+}
+
+// nameRe matches the characters a package or resource name may not contain
+var nameRe = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// FromTables builds a package from tables loaded with their records
+// The package is named after the database when all tables share one.
+// Paths of files under dir, the descriptor's directory, are written relative to it
+func FromTables(title string, created time.Time, dir string, tables []model.Table) *Package {
+	p := &Package{Profile: PackageProfile, Title: title, Resources: []Resource{}}
+	if !created.IsZero() {
+		p.Created = created.Format(time.RFC3339)
+	}
+
+	databases := map[string]bool{}
+	keywords := map[string]bool{}
+	for i := range tables {
+		t := &tables[i]
+		databases[t.Database] = true
+		for _, tag := range t.Tags {
+			if !keywords[tag] {
+				keywords[tag] = true
+				p.Keywords = append(p.Keywords, tag)
+			}
+		}
+	}
+	if len(databases) == 1 {
+		p.Name = Name(tables[0].Database)
+	}
+
+	used := map[string]bool{}
+	for i := range tables {
+		t := &tables[i]
+		name := Name(t.Name)
+		if len(databases) > 1 {
+			name = Name(t.Database + "." + t.Name)
+		}
+		p.Resources = append(p.Resources, resource(uniqueName(name, used), t, dir))
+	}
+	return p
+}
+
+// uniqueName returns name, or name with the first free -2, -3... suffix when
+// another resource already has it, and marks the result as used
+// Resource names must be unique, but different table names may slug alike
+func uniqueName(name string, used map[string]bool) string {
+	if name == "" {
+		name = "resource"
+	}
+	unique := name
+	for n := 2; used[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", name, n)
+	}
+	used[unique] = true
+	return unique
+}
+
+// resource converts a table to a resource
+func resource(name string, t *model.Table, dir string) Resource {
+	r := Resource{
+		Profile:     ResourceProfile,
+		Name:        name,
+		Description: t.Description,
+		Schema:      &Schema{Fields: []Field{}, PrimaryKey: splitKeys(t.Keys)},
+		SRDM: &Extra{
+			Name:       t.FullName(),
+			Engine:     t.Engine,
+			ScriptFile: t.ScriptFile,
+			ScriptTag:  t.ScriptTag,
+			DescFile:   t.DescFile,
+			DescTag:    t.DescTag,
+			LogFile:    t.LogFile,
+			Tags:       t.Tags,
+			Attributes: t.Attributes,
+		},
+	}
+	if t.Path != "" {
+		r.Path = stringList{RelativePath(t.Path, dir, false)}
+		r.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(t.Path)), ".")
+	}
+	if t.Source != "" {
+		r.Sources = []Source{{Title: t.Source}}
+	}
+	for i := range t.Records {
+		r.Schema.Fields = append(r.Schema.Fields, field(&t.Records[i]))
+	}
+	return r
+}
+
+// field converts a record to a schema field
+func field(rec *model.Record) Field {
+	f := Field{
+		Name:        rec.Name,
+		Type:        fieldType(rec.Type),
+		Title:       rec.Label,
+		Description: rec.Description,
+		SRDM: &Extra{
+			Name:         rec.FullName(),
+			Source:       rec.Source,
+			Number:       rec.Number,
+			MissNumber:   rec.MissNumber,
+			UniqueNumber: rec.UniqueNumber,
+			ScriptFile:   rec.ScriptFile,
+			ScriptTag:    rec.ScriptTag,
+			DescFile:     rec.DescFile,
+			DescTag:      rec.DescTag,
+			LogFile:      rec.LogFile,
+			Tags:         rec.Tags,
+			Attributes:   rec.Attributes,
+		},
+	}
+	if rec.Type != "" && recordType(f.Type) != rec.Type {
+		f.SRDM.Type = rec.Type
+	}
+	return f
+}
+
+// Tables converts the resources of a package to tables with their records
+// Resources exported by srdm keep their full names; others are placed in
+// database, and relative paths are resolved against dir, the descriptor's directory
+func (p *Package) Tables(database, dir string) ([]model.Table, error) {
+	tables := make([]model.Table, 0, len(p.Resources))
+	for i, r := range p.Resources {
+		if r.Name == "" {
+			return nil, fmt.Errorf("resource %d has no name", i+1)
+		}
+		x := r.SRDM
+		if x == nil {
+			x = &Extra{}
+		}
+		t := model.Table{
+			Database:    database,
+			Name:        r.Name,
+			Description: r.Description,
+			Engine:      x.Engine,
+			ScriptFile:  x.ScriptFile,
+			ScriptTag:   x.ScriptTag,
+			DescFile:    x.DescFile,
+			DescTag:     x.DescTag,
+			LogFile:     x.LogFile,
+			Tags:        x.Tags,
+			Attributes:  x.Attributes,
+		}
+		if db, name, ok := strings.Cut(x.Name, ":"); ok && !strings.Contains(name, ":") {
+			t.Database, t.Name = db, name
+		} else if database == "" {
+			return nil, fmt.Errorf("resource %s: the package has no name to use as database", r.Name)
+		}
+		if t.Description == "" {
+			t.Description = r.Title
+		}
+		if len(r.Path) > 0 {
+			t.Path = r.Path[0]
+			if dir != "" && !filepath.IsAbs(t.Path) && !strings.Contains(t.Path, "://") {
+				t.Path = filepath.Join(dir, filepath.FromSlash(t.Path))
+			}
+		}
+		if t.Engine == "" && r.Format != "" {
+			t.Engine = strings.ToUpper(r.Format)
+		}
+		if len(r.Sources) > 0 {
+			t.Source = r.Sources[0].Title
+			if t.Source == "" {
+				t.Source = r.Sources[0].Path
+			}
+		}
+
+		if r.Schema != nil {
+			t.Keys = strings.Join(r.Schema.PrimaryKey, ",")
+			for _, f := range r.Schema.Fields {
+				if f.Name == "" {
+					return nil, fmt.Errorf("resource %s: field without a name", r.Name)
+				}
+				t.Records = append(t.Records, record(&t, &f))
+			}
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// record converts a schema field to a record of table t
+func record(t *model.Table, f *Field) model.Record {
+	x := f.SRDM
+	if x == nil {
+		x = &Extra{}
+	}
+	rec := model.Record{
+		Database:     t.Database,
+		Table:        t.Name,
+		Name:         f.Name,
+		Type:         recordType(f.Type),
+		Label:        f.Title,
+		Description:  f.Description,
+		Source:       x.Source,
+		Number:       x.Number,
+		MissNumber:   x.MissNumber,
+		UniqueNumber: x.UniqueNumber,
+		ScriptFile:   x.ScriptFile,
+		ScriptTag:    x.ScriptTag,
+		DescFile:     x.DescFile,
+		DescTag:      x.DescTag,
+		LogFile:      x.LogFile,
+		Tags:         x.Tags,
+		Attributes:   x.Attributes,
+	}
+	if x.Type != "" {
+		rec.Type = x.Type
+	}
+	return rec
+}
+
+// Name turns text into a valid package or resource name: lower case letters,
+// digits and . _ - only
+func Name(s string) string {
+	return strings.Trim(nameRe.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// fieldType returns the Table Schema type of a record type
+func fieldType(t string) string {
+	if t == "" {
+		return ""
+	}
+	if ft, ok := fieldTypes[strings.ToLower(t)]; ok {
+		return ft
+	}
+	return "any"
+}
+
+// recordType returns the record type of a Table Schema type
+func recordType(t string) string {
+	if rt, ok := recordTypes[t]; ok {
+		return rt
+	}
+	return t
+}
+
+// RelativePath returns path relative to dir, with forward slashes, if it lies under dir
+// Other absolute paths are kept, or become file:// URIs when fileURI is set
+func RelativePath(path, dir string, fileURI bool) string {
+	if dir != "" && filepath.IsAbs(path) {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	if fileURI && filepath.IsAbs(path) {
+		return "file://" + filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(path)
+}
+
+// splitKeys splits table keys separated by commas or spaces
+func splitKeys(keys string) stringList {
+	return strings.FieldsFunc(keys, func(r rune) bool { return r == ',' || r == ' ' })
+}
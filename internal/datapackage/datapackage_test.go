@@ -0,0 +1,126 @@
+package datapackage
+
+import (
+	"encoding/json"
+	"reflect"
+	"srdm/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tables := []model.Table{{
+		Database: "proj", Name: "Survey 2024", Keys: "id, wave", Path: "/data/proj/survey.csv", Engine: "CSV",
+		Source: "field work", Tags: []string{"core"}, Attributes: map[string]string{"license": "CC-BY"},
+		Records: []model.Record{
+			{Database: "proj", Table: "Survey 2024", Name: "age", Type: "int", Label: "Age", Number: 10, MissNumber: 1},
+			{Database: "proj", Table: "Survey 2024", Name: "photo", Type: "blob"},
+		},
+	}}
+	p := FromTables("Project", time.Now(), "/data/proj", tables)
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"name":"proj"`, `"name":"survey-2024"`, `"path":"survey.csv"`,
+		`"primaryKey":["id","wave"]`, `"type":"integer"`, `"type":"any"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("descriptor missing %s:\n%s", want, data)
+		}
+	}
+
+	var back Package
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	got, err := back.Tables("", "/data/proj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := tables[0]
+	want.Keys = "id,wave"
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("round trip changed the table:\n got %+v\nwant %+v", got[0], want)
+	}
+}
+
+func TestForeignPackage(t *testing.T) {
+	data := `{"name": "climate", "resources": [
+		{"name": "temps", "path": ["data/temps.csv", "data/temps2.csv"], "format": "csv", "title": "Daily",
+		 "sources": [{"path": "https://example.org"}],
+		 "schema": {"fields": [{"name": "day", "type": "date"}, {"name": "tmax", "type": "number"}], "primaryKey": "day"}},
+		{"name": "inline"}]}`
+	var p Package
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		t.Fatal(err)
+	}
+	tables, err := p.Tables(p.Name, "/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	temps := tables[0]
+	if temps.FullName() != "climate:temps" || temps.Path != "/pkg/data/temps.csv" || temps.Keys != "day" ||
+		temps.Engine != "CSV" || temps.Description != "Daily" || temps.Source != "https://example.org" {
+		t.Errorf("unexpected table %+v", temps)
+	}
+	if len(temps.Records) != 2 || temps.Records[1].Type != "float" || temps.Records[1].FullName() != "climate:temps:tmax" {
+		t.Errorf("unexpected records %+v", temps.Records)
+	}
+
+	if _, err := p.Tables("", ""); err == nil {
+		t.Error("Expected an error for a package without a database name")
+	}
+}
+
+func TestUniqueResourceNames(t *testing.T) {
+	// Different table names may slug to the same resource name, or to none
+	tables := []model.Table{{Database: "a", Name: "x y"}, {Database: "a", Name: "X-Y"}, {Database: "a", Name: "x-y-2"}, {Database: "a", Name: "李四"}}
+	p := FromTables("", time.Time{}, "", tables)
+	var names []string
+	for _, r := range p.Resources {
+		names = append(names, r.Name)
+	}
+	if want := []string{"x-y", "x-y-2", "x-y-2-2", "resource"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Resource names = %q, want %q", names, want)
+	}
+
+	// The tables keep their own names on import
+	got, err := p.Tables("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tables {
+		if got[i].FullName() != tables[i].FullName() {
+			t.Errorf("Resource %s imported as %s", names[i], got[i].FullName())
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	for in, want := range map[string]string{"Survey 2024": "survey-2024", "a_b.c": "a_b.c", "--Ä--": ""} {
+		if got := Name(in); got != want {
+			t.Errorf("Name(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		path, dir string
+		fileURI   bool
+		want      string
+	}{
+		{"/data/pkg/temps.csv", "/data/pkg", false, "temps.csv"},
+		{"/data/pkg/raw/temps.csv", "/data/pkg", true, "raw/temps.csv"},
+		{"/data/other.csv", "/data/pkg", false, "/data/other.csv"},
+		{"/data/other.csv", "/data/pkg", true, "file:///data/other.csv"},
+		{"/data/pkg/temps.csv", "", true, "file:///data/pkg/temps.csv"},
+		{"temps.csv", "/data/pkg", true, "temps.csv"},
+	}
+	for _, tt := range tests {
+		if got := RelativePath(tt.path, tt.dir, tt.fileURI); got != tt.want {
+			t.Errorf("RelativePath(%q, %q, %v) = %q, want %q", tt.path, tt.dir, tt.fileURI, got, tt.want)
+		}
+	}
+}
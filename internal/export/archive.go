@@ -0,0 +1,209 @@
+package export
+
+import (
+	"fmt"
+	"mime"
+	"path/filepath"
+	"regexp"
+	"srdm/internal/model"
+	"strings"
+)
+
+// Dataset metadata that tables and records do not hold is read from custom
+// attributes of the exported tables, the first table with a value winning:
+//
+//	title        title of the dataset (default: --title)
+//	description  abstract (default: the table description)
+//	creator      people or organisations, separated by ";", each optionally
+//	             followed by an ORCID in parentheses: "Lee, Ann (0000-0002-1825-0097)"
+//	license      SPDX identifier such as CC-BY-4.0, or a URL
+//	doi          DOI of the dataset, also read from identifier
+//	publisher    repository or institution publishing the dataset
+//	published    publication date, YYYY or YYYY-MM-DD (default: the export date)
+//	keywords     keywords separated by ";" or ",", added to the tags
+var datasetAttributes = map[string][]string{
+	"title":       {"title"},
+	"description": {"description", "abstract"},
+	"creator":     {"creator", "creators", "author"},
+	"license":     {"license"},
+	"doi":         {"doi", "identifier"},
+	"publisher":   {"publisher"},
+	"published":   {"published", "datepublished", "publicationyear", "year"},
+	"keywords":    {"keywords"},
+}
+
+// dataset is the dataset-level metadata of an archive export
+type dataset struct {
+	Tables      []model.Table
+	Owner       string // Full name of the first table, where missing attributes should be set
+	Title       string
+	Description string
+	Creators    []creator
+	License     string
+	DOI         string // Bare DOI, without resolver prefix
+	Publisher   string
+	Published   string
+	Keywords    []string
+}
+
+// creator is a person or organisation with an optional ORCID iD
+type creator struct {
+	Name  string
+	ORCID string
+}
+
+var (
+	orcidRe = regexp.MustCompile(`^(.*?)\s*\(\s*(?:https?://orcid\.org/)?(\d{4}-\d{4}-\d{4}-\d{3}[\dX])\s*\)$`)
+	doiRe   = regexp.MustCompile(`^(?i:(?:https?://(?:dx\.)?doi\.org/|doi:))?(10\.\d{4,}/\S+)$`)
+	slugRe  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// newDataset collects the dataset metadata of a catalog of whole tables
+func newDataset(format string, c *Catalog) (*dataset, error) {
+	if err := requireTables(format, c); err != nil {
+		return nil, err
+	}
+	d := &dataset{Tables: c.Tables}
+	if len(c.Tables) > 0 {
+		d.Owner = c.Tables[0].FullName()
+	}
+	d.Title = d.attr("title")
+	if d.Title == "" {
+		d.Title = c.Title
+	}
+	d.Description = d.attr("description")
+	for i := 0; d.Description == "" && i < len(c.Tables); i++ {
+		d.Description = c.Tables[i].Description
+	}
+	for _, s := range strings.Split(d.attr("creator"), ";") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		if m := orcidRe.FindStringSubmatch(s); m != nil {
+			d.Creators = append(d.Creators, creator{Name: m[1], ORCID: m[2]})
+		} else {
+			d.Creators = append(d.Creators, creator{Name: s})
+		}
+	}
+	d.License = d.attr("license")
+	d.DOI = d.attr("doi")
+	if m := doiRe.FindStringSubmatch(d.DOI); m != nil {
+		d.DOI = m[1]
+	}
+	d.Publisher = d.attr("publisher")
+	d.Published = d.attr("published")
+	if d.Published == "" && !c.Generated.IsZero() {
+		d.Published = c.Generated.Format("2006-01-02")
+	}
+
+	seen := map[string]bool{}
+	add := func(k string) {
+		if k = strings.TrimSpace(k); k != "" && !seen[k] {
+			seen[k] = true
+			d.Keywords = append(d.Keywords, k)
+		}
+	}
+	for _, t := range c.Tables {
+		for _, tag := range t.Tags {
+			add(tag)
+		}
+	}
+	for _, k := range strings.FieldsFunc(d.attr("keywords"), func(r rune) bool { return r == ';' || r == ',' }) {
+		add(k)
+	}
+	return d, nil
+}
+
+// attr returns the first value of a dataset attribute among the tables
+// Attribute keys are matched case-insensitively
+func (d *dataset) attr(field string) string {
+	for _, t := range d.Tables {
+		for _, key := range datasetAttributes[field] {
+			for k, v := range t.Attributes {
+				if strings.EqualFold(k, key) && strings.TrimSpace(v) != "" {
+					return strings.TrimSpace(v)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// missing returns a problem for a required dataset field without a value
+func (d *dataset) missing(field, hint string) Problem {
+	msg := fmt.Sprintf("missing %s, set attr.%s", field, datasetAttributes[field][0])
+	if hint != "" {
+		msg += " " + hint
+	}
+	return Problem{Name: d.Owner, Field: field, Message: msg}
+}
+
+// year returns the publication year
+func (d *dataset) year() string {
+	if len(d.Published) >= 4 {
+		return d.Published[:4]
+	}
+	return ""
+}
+
+// joinNonEmpty joins the non-empty parts with sep
+func joinNonEmpty(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// licenseURL returns the URL of a license given as URL or SPDX identifier
+func licenseURL(license string) string {
+	if strings.Contains(license, "://") {
+		return license
+	}
+	return "https://spdx.org/licenses/" + license
+}
+
+// mediaTypes are the media types of common data files missing from the mime package
+var mediaTypes = map[string]string{
+	".csv":     "text/csv",
+	".tsv":     "text/tab-separated-values",
+	".tab":     "text/tab-separated-values",
+	".txt":     "text/plain",
+	".json":    "application/json",
+	".parquet": "application/vnd.apache.parquet",
+	".sqlite":  "application/vnd.sqlite3",
+	".db":      "application/vnd.sqlite3",
+	".xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".dta":     "application/x-stata-dta",
+	".sav":     "application/x-spss-sav",
+	".rds":     "application/octet-stream",
+}
+
+// mediaType guesses the media type of a data file from its extension
+func mediaType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if t, ok := mediaTypes[ext]; ok {
+		return t
+	}
+	if ext == "" {
+		return ""
+	}
+	t := mime.TypeByExtension(ext)
+	if i := strings.Index(t, ";"); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
+
+// requireTables rejects records exported without their table, which formats
+// describing whole tables have no place for
+func requireTables(format string, c *Catalog) error {
+	if len(c.Records) == 0 {
+		return nil
+	}
+	r := c.Records[0]
+	return fmt.Errorf("%s describes whole tables; %s is selected without its table (export %s:%s instead)",
+		format, r.FullName(), r.Database, r.Table)
+}
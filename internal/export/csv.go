@@ -0,0 +1,122 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"srdm/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("csv", ExporterFunc(writeCSV), ".csv")
+}
+
+// csvColumns are the fixed columns of the CSV output, named as `srdm import` reads them
+// Tables leave the record columns empty and the other way round
+var csvColumns = []string{
+	"name", "keys", "path", "engine", "type", "label", "source", "description",
+	"number", "missNumber", "uniqueNumber",
+	"script_file", "script_tag", "desc_file", "desc_tag", "log_file",
+	"tags", "create_at", "modify_at",
+}
+
+// writeCSV writes one row per table and record, tables first
+// Names are full names, tags are separated by ";" and attributes get attr.KEY columns
+func writeCSV(w io.Writer, c *Catalog) error {
+	var rows []map[string]string
+	attrKeys := map[string]bool{}
+	add := func(row map[string]string, attrs map[string]string) {
+		for k, v := range attrs {
+			row["attr."+k] = v
+			attrKeys[k] = true
+		}
+		rows = append(rows, row)
+	}
+	for i := range c.Tables {
+		t := &c.Tables[i]
+		add(tableRow(t), t.Attributes)
+	}
+	for i := range c.Tables {
+		for j := range c.Tables[i].Records {
+			r := &c.Tables[i].Records[j]
+			add(recordRow(r), r.Attributes)
+		}
+	}
+	for i := range c.Records {
+		add(recordRow(&c.Records[i]), c.Records[i].Attributes)
+	}
+
+	header := append([]string{}, csvColumns...)
+	for _, k := range sortedKeys(attrKeys) {
+		header = append(header, "attr."+k)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	line := make([]string, len(header))
+	for _, row := range rows {
+		for i, col := range header {
+			line[i] = row[col]
+		}
+		if err := cw.Write(line); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// tableRow returns the CSV columns of a table
+func tableRow(t *model.Table) map[string]string {
+	return map[string]string{
+		"name":        t.FullName(),
+		"keys":        t.Keys,
+		"path":        t.Path,
+		"engine":      t.Engine,
+		"source":      t.Source,
+		"description": t.Description,
+		"script_file": t.ScriptFile,
+		"script_tag":  t.ScriptTag,
+		"desc_file":   t.DescFile,
+		"desc_tag":    t.DescTag,
+		"log_file":    t.LogFile,
+		"tags":        strings.Join(t.Tags, ";"),
+		"create_at":   formatTime(t.CreateAt),
+		"modify_at":   formatTime(t.ModifyAt),
+	}
+}
+
+// recordRow returns the CSV columns of a record
+func recordRow(r *model.Record) map[string]string {
+	return map[string]string{
+		"name":         r.FullName(),
+		"type":         r.Type,
+		"label":        r.Label,
+		"source":       r.Source,
+		"description":  r.Description,
+		"number":       strconv.Itoa(r.Number),
+		"missNumber":   strconv.Itoa(r.MissNumber),
+		"uniqueNumber": strconv.Itoa(r.UniqueNumber),
+		"script_file":  r.ScriptFile,
+		"script_tag":   r.ScriptTag,
+		"desc_file":    r.DescFile,
+		"desc_tag":     r.DescTag,
+		"log_file":     r.LogFile,
+		"tags":         strings.Join(r.Tags, ";"),
+		"create_at":    formatTime(r.CreateAt),
+		"modify_at":    formatTime(r.ModifyAt),
+	}
+}
+
+// formatTime formats a time as RFC3339, or "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
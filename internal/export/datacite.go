@@ -0,0 +1,193 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	Register("datacite", dataCite{}, "datacite.xml", ".xml")
+}
+
+// dataCite writes a DataCite Metadata Schema 4 record of the exported tables
+// Dataset fields come from table attributes (see datasetAttributes); the tables
+// and their records are listed in a TableOfContents description
+type dataCite struct{}
+
+// DataCite kernel 4 namespace and schema location
+const (
+	dataCiteNamespace = "http://datacite.org/schema/kernel-4"
+	dataCiteSchema    = "http://datacite.org/schema/kernel-4 https://schema.datacite.org/meta/kernel-4/metadata.xsd"
+)
+
+type dcResource struct {
+	XMLName        xml.Name        `xml:"resource"`
+	Namespace      string          `xml:"xmlns,attr"`
+	XSI            string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Identifier     dcIdentifier    `xml:"identifier"`
+	Creators       []dcCreator     `xml:"creators>creator"`
+	Titles         []string        `xml:"titles>title"`
+	Publisher      string          `xml:"publisher"`
+	Year           string          `xml:"publicationYear"`
+	ResourceType   dcResourceType  `xml:"resourceType"`
+	Subjects       []string        `xml:"subjects>subject,omitempty"`
+	Dates          []dcTyped       `xml:"dates>date,omitempty"`
+	Formats        []string        `xml:"formats>format,omitempty"`
+	Rights         []dcRights      `xml:"rightsList>rights,omitempty"`
+	Descriptions   []dcDescription `xml:"descriptions>description,omitempty"`
+}
+
+type dcIdentifier struct {
+	Type  string `xml:"identifierType,attr"`
+	Value string `xml:",chardata"`
+}
+
+type dcCreator struct {
+	Name       string            `xml:"creatorName"`
+	Identifier *dcNameIdentifier `xml:"nameIdentifier,omitempty"`
+}
+
+type dcNameIdentifier struct {
+	Scheme    string `xml:"nameIdentifierScheme,attr"`
+	SchemeURI string `xml:"schemeURI,attr"`
+	Value     string `xml:",chardata"`
+}
+
+type dcResourceType struct {
+	General string `xml:"resourceTypeGeneral,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type dcTyped struct {
+	Type  string `xml:"dateType,attr"`
+	Value string `xml:",chardata"`
+}
+
+type dcRights struct {
+	URI        string `xml:"rightsURI,attr,omitempty"`
+	Identifier string `xml:"rightsIdentifier,attr,omitempty"`
+	Scheme     string `xml:"rightsIdentifierScheme,attr,omitempty"`
+	Value      string `xml:",chardata"`
+}
+
+type dcDescription struct {
+	Type  string `xml:"descriptionType,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Validate reports the mandatory DataCite properties without a value
+func (dataCite) Validate(c *Catalog) []Problem {
+	d, err := newDataset("a DataCite record", c)
+	if err != nil {
+		return nil // Export reports the selection
+	}
+	var problems []Problem
+	if d.DOI == "" {
+		problems = append(problems, d.missing("doi", "(the DOI reserved at the repository)"))
+	} else if !doiRe.MatchString(d.DOI) {
+		problems = append(problems, Problem{Name: d.Owner, Field: "doi", Message: fmt.Sprintf("%q is not a DOI (expected 10.PREFIX/SUFFIX)", d.DOI)})
+	}
+	if len(d.Creators) == 0 {
+		problems = append(problems, d.missing("creator", `(e.g. "Lee, Ann (0000-0002-1825-0097); Chen, Bo")`))
+	}
+	if d.Title == "" {
+		problems = append(problems, d.missing("title", "or use --title"))
+	}
+	if d.Publisher == "" {
+		problems = append(problems, d.missing("publisher", ""))
+	}
+	if d.year() == "" {
+		problems = append(problems, d.missing("published", ""))
+	}
+	return problems
+}
+
+// Export writes the DataCite XML record
+func (dataCite) Export(w io.Writer, c *Catalog) error {
+	d, err := newDataset("a DataCite record", c)
+	if err != nil {
+		return err
+	}
+
+	res := dcResource{
+		Namespace:      dataCiteNamespace,
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: dataCiteSchema,
+		Identifier:     dcIdentifier{Type: "DOI", Value: d.DOI},
+		Titles:         []string{d.Title},
+		Publisher:      d.Publisher,
+		Year:           d.year(),
+		ResourceType:   dcResourceType{General: "Dataset", Value: "Tabular data"},
+		Subjects:       d.Keywords,
+	}
+	for _, p := range d.Creators {
+		cr := dcCreator{Name: p.Name}
+		if p.ORCID != "" {
+			cr.Identifier = &dcNameIdentifier{Scheme: "ORCID", SchemeURI: "https://orcid.org", Value: "https://orcid.org/" + p.ORCID}
+		}
+		res.Creators = append(res.Creators, cr)
+	}
+	if len(d.Published) == len("2006-01-02") {
+		res.Dates = append(res.Dates, dcTyped{Type: "Issued", Value: d.Published})
+	}
+	for _, t := range c.Tables {
+		if !t.CreateAt.IsZero() {
+			res.Dates = append(res.Dates, dcTyped{Type: "Created", Value: t.CreateAt.Format("2006-01-02")})
+			break
+		}
+	}
+	formats := map[string]bool{}
+	for _, t := range c.Tables {
+		if mt := mediaType(t.Path); mt != "" && !formats[mt] {
+			formats[mt] = true
+			res.Formats = append(res.Formats, mt)
+		}
+	}
+	if d.License != "" {
+		r := dcRights{URI: licenseURL(d.License), Value: d.License}
+		if !strings.Contains(d.License, "://") {
+			r.Identifier, r.Scheme = d.License, "SPDX"
+		}
+		res.Rights = []dcRights{r}
+	}
+	if d.Description != "" {
+		res.Descriptions = append(res.Descriptions, dcDescription{Type: "Abstract", Value: d.Description})
+	}
+	if toc := tableOfContents(c); toc != "" {
+		res.Descriptions = append(res.Descriptions, dcDescription{Type: "TableOfContents", Value: toc})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		return fmt.Errorf("failed to encode DataCite XML: %w", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// tableOfContents lists the tables with their variables and types, one table per line
+func tableOfContents(c *Catalog) string {
+	var lines []string
+	for _, t := range c.Tables {
+		vars := make([]string, len(t.Records))
+		for i, r := range t.Records {
+			vars[i] = r.Name
+			if r.Type != "" {
+				vars[i] += " (" + r.Type + ")"
+			}
+		}
+		line := t.FullName()
+		if len(vars) > 0 {
+			line += ": " + strings.Join(vars, ", ")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
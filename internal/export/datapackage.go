@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"srdm/internal/datapackage"
+)
+
+func init() {
+	Register("datapackage", ExporterFunc(writeDataPackage), "datapackage.json")
+}
+
+// writeDataPackage writes a Frictionless Data Package descriptor with one resource per table
+func writeDataPackage(w io.Writer, c *Catalog) error {
+	if err := requireTables("a data package", c); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(datapackage.FromTables(c.Title, c.Generated, c.Dir, c.Tables)); err != nil {
+		return fmt.Errorf("failed to encode data package: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package export writes tables and records in the formats of `srdm export`
+//
+// Every format is an Exporter registered under its name, so new formats
+// plug in with a call to Register from their own file:
+//
+//	func init() { Register("csv", ExporterFunc(writeCSV), ".csv") }
+//
+// Exporters receive a Catalog holding the selected tables, each with its
+// records, and the records selected without their table.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"srdm/internal/model"
+	"strings"
+	"time"
+)
+
+// Catalog is the set of items to export
+type Catalog struct {
+	Title     string         // Title of document formats such as html and markdown
+	Generated time.Time      // Time of the export
+	Dir       string         // Directory of the output file, "" for stdout
+	Tables    []model.Table  // Exported tables, each with its records
+	Records   []model.Record // Records exported without their table
+}
+
+// Items returns the tables followed by the records, the list shape read by `srdm import`
+func (c *Catalog) Items() []any {
+	items := make([]any, 0, len(c.Tables)+len(c.Records))
+	for i := range c.Tables {
+		items = append(items, &c.Tables[i])
+	}
+	for i := range c.Records {
+		items = append(items, &c.Records[i])
+	}
+	return items
+}
+
+// RecordCount returns the number of records, nested or not
+func (c *Catalog) RecordCount() int {
+	n := len(c.Records)
+	for _, t := range c.Tables {
+		n += len(t.Records)
+	}
+	return n
+}
+
+// Exporter writes a catalog in one format
+type Exporter interface {
+	Export(w io.Writer, c *Catalog) error
+}
+
+// Validator is implemented by exporters whose format has required fields
+// Validate reports every required field the catalog cannot fill; the export
+// is only written when there are none
+type Validator interface {
+	Validate(c *Catalog) []Problem
+}
+
+// Problem is a required field missing for an export format
+type Problem struct {
+	Name    string `json:"name"`    // Table or record expected to hold the value
+	Field   string `json:"field"`   // Field of the export format
+	Message string `json:"message"` // What to set
+}
+
+// ErrIncomplete means the catalog lacks fields required by the export format
+var ErrIncomplete = errors.New("incomplete metadata")
+
+// ExporterFunc adapts an ordinary function to an Exporter
+type ExporterFunc func(w io.Writer, c *Catalog) error
+
+// Export calls f(w, c)
+func (f ExporterFunc) Export(w io.Writer, c *Catalog) error {
+	return f(w, c)
+}
+
+var (
+	exporters  = map[string]Exporter{}
+	extensions = map[string]string{} // File extension or name to format
+)
+
+// Register makes an exporter available under a format name
+// exts are the file extensions, with the dot, or whole file names that select the format by default.
+// It panics if the format is registered twice
+func Register(format string, e Exporter, exts ...string) {
+	if _, dup := exporters[format]; dup {
+		panic("export: format registered twice: " + format)
+	}
+	exporters[format] = e
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = format
+	}
+}
+
+// Lookup returns the exporter of a format
+func Lookup(format string) (Exporter, error) {
+	e, ok := exporters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported export format %q (use %s)", format, strings.Join(Formats(), ", "))
+	}
+	return e, nil
+}
+
+// Formats returns the registered format names, sorted
+func Formats() []string {
+	formats := make([]string, 0, len(exporters))
+	for f := range exporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// FormatForFile returns the format registered for the name or extension of path, or ""
+func FormatForFile(path string) string {
+	if format, ok := extensions[strings.ToLower(filepath.Base(path))]; ok {
+		return format
+	}
+	return extensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
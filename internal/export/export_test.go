@@ -0,0 +1,225 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"srdm/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCatalog() *Catalog {
+	return &Catalog{
+		Tables: []model.Table{{
+			Database: "db", Name: "t", Keys: "id", Description: "Survey | wave 1",
+			Attributes: map[string]string{"license": "CC-BY"},
+			Records: []model.Record{
+				{Database: "db", Table: "t", Name: "age", Type: "int", Label: "Age <years>", Number: 5},
+			},
+		}},
+		Records: []model.Record{
+			{Database: "db", Table: "other", Name: "x", Attributes: map[string]string{"unit": "kg"}},
+		},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	want := []string{"csv", "datacite", "datapackage", "html", "json", "jsonl", "markdown", "rocrate", "yaml"}
+	if got := Formats(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Formats() = %v, want %v", got, want)
+	}
+	for path, format := range map[string]string{"a.JSON": "json", "a.ndjson": "jsonl", "a.yml": "yaml", "a.md": "markdown", "a.htm": "html", "a.txt": "", "out/datapackage.json": "datapackage", "ro-crate-metadata.json": "rocrate", "a.xml": "datacite"} {
+		if got := FormatForFile(path); got != format {
+			t.Errorf("FormatForFile(%q) = %q, want %q", path, got, format)
+		}
+	}
+	if _, err := Lookup("pdf"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestExporters(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{`"name": "t"`, `"name": "age"`, `"unit": "kg"`}},
+		{"jsonl", []string{`"records":[{`, "\n{\"database\":\"db\",\"table\":\"other\""}},
+		{"yaml", []string{"keys: id", "missNumber: 0", "license: CC-BY"}},
+		{"csv", []string{"attr.license,attr.unit\n", "db:t,id,", "db:t:age,", "db:other:x,", ",kg\n"}},
+		{"markdown", []string{"# Data Catalogue", "## db:t", "Survey | wave 1", "| license | CC-BY |", "| age | int | Age &lt;years> | 5 |", "| db:other:x |"}},
+		{"html", []string{"<title>Data Catalogue</title>", `<a href="#db-t">db:t</a>`, "Age &lt;years&gt;", "<code>db:other:x</code>", "unit=kg"}},
+	}
+	for _, tt := range tests {
+		e, err := Lookup(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := e.Export(&buf, testCatalog()); err != nil {
+			t.Fatalf("%s export failed: %v", tt.format, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("%s output missing %q:\n%s", tt.format, w, buf.String())
+			}
+		}
+	}
+}
+
+func TestJSONItemsShape(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, testCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[1]["table"] != "other" {
+		t.Errorf("Expected the table then the standalone record, got %v", items)
+	}
+}
+
+func TestArchiveValidation(t *testing.T) {
+	c := testCatalog()
+	c.Records = nil
+	c.Generated = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for format, want := range map[string][]string{
+		"rocrate":  {"title"},
+		"datacite": {"doi", "creator", "title", "publisher"},
+	} {
+		e, _ := Lookup(format)
+		problems := e.(Validator).Validate(c)
+		var fields []string
+		for _, p := range problems {
+			fields = append(fields, p.Field)
+			if p.Name != "db:t" {
+				t.Errorf("%s problem not attached to the table: %+v", format, p)
+			}
+		}
+		if strings.Join(fields, ",") != strings.Join(want, ",") {
+			t.Errorf("%s missing fields = %v, want %v", format, fields, want)
+		}
+	}
+
+	c.Tables[0].Attributes = map[string]string{
+		"Title": "Survey", "license": "CC-BY-4.0", "doi": "doi:10.5281/zenodo.123",
+		"creator": "Lee, Ann (https://orcid.org/0000-0002-1825-0097); Chen, Bo", "publisher": "Zenodo",
+	}
+	for format, want := range map[string][]string{
+		"rocrate": {`"identifier": "https://doi.org/10.5281/zenodo.123"`, `"@id": "https://orcid.org/0000-0002-1825-0097"`,
+			`"@id": "https://spdx.org/licenses/CC-BY-4.0"`, `"@id": "#db-t-age"`},
+		"datacite": {`<identifier identifierType="DOI">10.5281/zenodo.123</identifier>`, "<creatorName>Chen, Bo</creatorName>",
+			`rightsIdentifier="CC-BY-4.0"`, "db:t: age (int)"},
+	} {
+		e, _ := Lookup(format)
+		if problems := e.(Validator).Validate(c); len(problems) > 0 {
+			t.Errorf("%s still incomplete: %+v", format, problems)
+		}
+		var buf bytes.Buffer
+		if err := e.Export(&buf, c); err != nil {
+			t.Fatalf("%s export failed: %v", format, err)
+		}
+		for _, w := range want {
+			if !strings.Contains(buf.String(), w) {
+				t.Errorf("%s output missing %q:\n%s", format, w, buf.String())
+			}
+		}
+	}
+
+	// Records without their table cannot be placed
+	e, _ := Lookup("datacite")
+	if err := e.Export(&bytes.Buffer{}, testCatalog()); err == nil {
+		t.Error("Expected an error for a record exported without its table")
+	}
+}
+
+// crateGraph exports c as an RO-Crate and returns its entities by id, failing on duplicate ids
+func crateGraph(t *testing.T, c *Catalog) map[string]map[string]any {
+	t.Helper()
+	e, _ := Lookup("rocrate")
+	var buf bytes.Buffer
+	if err := e.Export(&buf, c); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var crate struct {
+		Graph []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &crate); err != nil {
+		t.Fatal(err)
+	}
+	entities := map[string]map[string]any{}
+	for _, e := range crate.Graph {
+		id, _ := e["@id"].(string)
+		if _, dup := entities[id]; dup || id == "" || id == "#" {
+			t.Errorf("Invalid or duplicate @id %q", id)
+		}
+		entities[id] = e
+	}
+	return entities
+}
+
+func TestROCrateSharedFile(t *testing.T) {
+	c := &Catalog{Dir: "/data", Tables: []model.Table{
+		{Database: "db", Name: "a", Path: "/data/study.sqlite", Description: "First", Tags: []string{"raw"},
+			Records: []model.Record{{Database: "db", Table: "a", Name: "x"}}},
+		{Database: "db", Name: "b", Path: "/data/study.sqlite", Description: "Second", Tags: []string{"raw", "clean"},
+			Records: []model.Record{{Database: "db", Table: "b", Name: "y"}}},
+	}}
+	file := crateGraph(t, c)["study.sqlite"]
+	if file == nil {
+		t.Fatal("No entity for the shared data file")
+	}
+	if file["name"] != "db:a, db:b" || file["description"] != "First\n\nSecond" {
+		t.Errorf("Tables not merged: %v", file)
+	}
+	if vars, _ := file["variableMeasured"].([]any); len(vars) != 2 {
+		t.Errorf("Expected the variables of both tables, got %v", file["variableMeasured"])
+	}
+	if kw, _ := file["keywords"].([]any); len(kw) != 2 {
+		t.Errorf("Expected merged keywords, got %v", file["keywords"])
+	}
+}
+
+func TestROCrateNonASCIINames(t *testing.T) {
+	c := testCatalog()
+	c.Records = nil
+	c.Tables[0].Attributes = map[string]string{"creator": "李四; 王五", "publisher": "北京大学"}
+	entities := crateGraph(t, c)
+	for _, id := range []string{"#creator-1", "#creator-2", "#publisher"} {
+		if entities[id] == nil {
+			t.Errorf("No entity %s", id)
+		}
+	}
+	if entities["#creator-2"]["name"] != "王五" {
+		t.Errorf("Unexpected second creator: %v", entities["#creator-2"])
+	}
+}
+
+func TestROCrateDistinctLocalIDs(t *testing.T) {
+	c := testCatalog()
+	c.Records = nil
+	c.Tables[0].Attributes = map[string]string{
+		"creator":   "Lee, Ann; Lee, Ann; Chen, Bo (0000-0002-1825-0097); Chen, Bo (0000-0002-1825-0097)",
+		"publisher": "Lee Ann",
+	}
+	// Full names a-b:c and a:b-c give the same anchor
+	c.Tables = append(c.Tables,
+		model.Table{Database: "a-b", Name: "c", Records: []model.Record{{Database: "a-b", Table: "c", Name: "x"}}},
+		model.Table{Database: "a", Name: "b-c", Records: []model.Record{{Database: "a", Table: "b-c", Name: "x"}}},
+	)
+	entities := crateGraph(t, c)
+	for _, id := range []string{"#lee-ann", "#lee-ann-2", "#lee-ann-3", "#a-b-c", "#a-b-c-2", "#a-b-c-x", "#a-b-c-x-2"} {
+		if entities[id] == nil {
+			t.Errorf("No entity %s", id)
+		}
+	}
+	if entities["#lee-ann"]["@type"] != "Organization" || entities["#lee-ann-3"]["@type"] != "Person" {
+		t.Errorf("Unexpected people: %v, %v", entities["#lee-ann"], entities["#lee-ann-3"])
+	}
+	if creators, _ := entities["./"]["creator"].([]any); len(creators) != 3 {
+		t.Errorf("Expected three creators, the ORCID once, got %v", entities["./"]["creator"])
+	}
+}
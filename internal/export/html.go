@@ -0,0 +1,116 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"srdm/internal/model"
+	"strings"
+)
+
+func init() {
+	Register("html", ExporterFunc(writeHTML), ".html", ".htm")
+}
+
+// htmlPage is a single self-contained catalogue page: styles are inline and
+// nothing is loaded from elsewhere, so the file can be mailed or archived as is
+var htmlPage = template.Must(template.New("catalogue").Funcs(template.FuncMap{
+	"anchor":     anchor,
+	"properties": tableProperties,
+	"note":       recordNote,
+	"date":       func(c *Catalog) string { return c.Generated.Format("2006-01-02 15:04") },
+	"list":       func(r []model.Record, full bool) recordList { return recordList{r, full} },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="generator" content="srdm">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, -apple-system, "Segoe UI", sans-serif; margin: 0 auto; max-width: 72rem; padding: 1rem 2rem; color: #222; line-height: 1.45; }
+h1 { border-bottom: 2px solid #345; padding-bottom: .3rem; }
+h2 { margin-top: 2.5rem; color: #345; }
+nav ul { columns: 2; padding-left: 1.2rem; }
+table { border-collapse: collapse; width: 100%; margin: .8rem 0; font-size: .92rem; }
+th, td { border: 1px solid #ccd; padding: .3rem .5rem; text-align: left; vertical-align: top; }
+th { background: #eef1f5; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+table.props { width: auto; }
+table.props th { background: none; font-weight: 600; }
+.meta { color: #667; font-size: .9rem; }
+code { font-family: ui-monospace, monospace; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{if not .Catalog.Generated.IsZero}}Generated {{date .Catalog}}. {{end}}{{len .Catalog.Tables}} tables, {{.Catalog.RecordCount}} records.</p>
+{{- if .Catalog.Tables}}
+<nav>
+<h2>Contents</h2>
+<ul>
+{{- range .Catalog.Tables}}
+<li><a href="#{{anchor .FullName}}">{{.FullName}}</a>{{with .Records}} ({{len .}}){{end}}</li>
+{{- end}}
+{{- if .Catalog.Records}}
+<li><a href="#records">Records</a> ({{len .Catalog.Records}})</li>
+{{- end}}
+</ul>
+</nav>
+{{- end}}
+{{- range .Catalog.Tables}}
+<section id="{{anchor .FullName}}">
+<h2><code>{{.FullName}}</code></h2>
+{{- with .Description}}
+<p>{{.}}</p>
+{{- end}}
+<table class="props">
+{{- range properties .}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+{{- with .Records}}
+{{template "records" list . false}}
+{{- end}}
+</section>
+{{- end}}
+{{- with .Catalog.Records}}
+<section id="records">
+<h2>Records</h2>
+{{template "records" list . true}}
+</section>
+{{- end}}
+</body>
+</html>
+{{define "records"}}<table>
+<thead><tr><th>Name</th><th>Type</th><th>Label</th><th>N</th><th>Missing</th><th>Unique</th><th>Description</th></tr></thead>
+<tbody>
+{{- $full := .Full}}
+{{- range .Records}}
+<tr id="{{anchor .FullName}}"><td><code>{{if $full}}{{.FullName}}{{else}}{{.Name}}{{end}}</code></td><td>{{.Type}}</td><td>{{.Label}}</td><td class="num">{{.Number}}</td><td class="num">{{.MissNumber}}</td><td class="num">{{.UniqueNumber}}</td><td>{{note .}}</td></tr>
+{{- end}}
+</tbody>
+</table>{{end}}
+`))
+
+// writeHTML writes the catalogue as one HTML page with a table of contents
+func writeHTML(w io.Writer, c *Catalog) error {
+	if err := htmlPage.Execute(w, struct {
+		Title   string
+		Catalog *Catalog
+	}{title(c), c}); err != nil {
+		return fmt.Errorf("failed to write HTML: %w", err)
+	}
+	return nil
+}
+
+// anchor turns a full name into an HTML id
+func anchor(name string) string {
+	return strings.ReplaceAll(name, ":", "-")
+}
+
+// recordList is the argument of the records template
+type recordList struct {
+	Records []model.Record
+	Full    bool // Show full names
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func init() {
+	Register("json", ExporterFunc(writeJSON), ".json")
+	Register("jsonl", ExporterFunc(writeJSONL), ".jsonl", ".ndjson")
+	Register("yaml", ExporterFunc(writeYAML), ".yaml", ".yml")
+}
+
+// writeJSON writes the items as one indented JSON list
+func writeJSON(w io.Writer, c *Catalog) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c.Items()); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
+// writeJSONL writes one compact JSON object per line
+// A table's line holds its records, like in the JSON list
+func writeJSONL(w io.Writer, c *Catalog) error {
+	enc := json.NewEncoder(w)
+	for _, item := range c.Items() {
+		if err := enc.Encode(item); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeYAML writes the items as a YAML list with the field names of the JSON output
+func writeYAML(w io.Writer, c *Catalog) error {
+	// The models only carry JSON tags; round-trip through JSON to keep their names
+	data, err := json.Marshal(c.Items())
+	if err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	var items any
+	if err := json.Unmarshal(data, &items); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(items); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"srdm/internal/model"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("markdown", ExporterFunc(writeMarkdown), ".md", ".markdown")
+}
+
+// DefaultTitle is the title of document formats when the catalog has none
+const DefaultTitle = "Data Catalogue"
+
+// writeMarkdown writes a catalogue document: one section per table with its
+// metadata and a table of its records, then the records exported on their own
+func writeMarkdown(w io.Writer, c *Catalog) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# %s\n\n", mdText(title(c)))
+	if !c.Generated.IsZero() {
+		fmt.Fprintf(b, "Generated %s. ", c.Generated.Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(b, "%d tables, %d records.\n", len(c.Tables), c.RecordCount())
+
+	for i := range c.Tables {
+		t := &c.Tables[i]
+		fmt.Fprintf(b, "\n## %s\n\n", mdText(t.FullName()))
+		if t.Description != "" {
+			fmt.Fprintf(b, "%s\n\n", t.Description)
+		}
+		fmt.Fprintln(b, "| Property | Value |")
+		fmt.Fprintln(b, "| --- | --- |")
+		for _, p := range tableProperties(t) {
+			fmt.Fprintf(b, "| %s | %s |\n", mdCell(p[0]), mdCell(p[1]))
+		}
+		if len(t.Records) > 0 {
+			fmt.Fprintln(b)
+			writeMarkdownRecords(b, t.Records, false)
+		}
+	}
+
+	if len(c.Records) > 0 {
+		fmt.Fprintf(b, "\n## Records\n\n")
+		writeMarkdownRecords(b, c.Records, true)
+	}
+	return b.Flush()
+}
+
+// writeMarkdownRecords writes a table of records, by full name if fullNames is set
+func writeMarkdownRecords(b *bufio.Writer, records []model.Record, fullNames bool) {
+	fmt.Fprintln(b, "| Name | Type | Label | N | Missing | Unique | Description |")
+	fmt.Fprintln(b, "| --- | --- | --- | ---: | ---: | ---: | --- |")
+	for i := range records {
+		r := &records[i]
+		name := r.Name
+		if fullNames {
+			name = r.FullName()
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %d | %d | %d | %s |\n",
+			mdCell(name), mdCell(r.Type), mdCell(r.Label),
+			r.Number, r.MissNumber, r.UniqueNumber, mdCell(recordNote(r)))
+	}
+}
+
+// tableProperties returns the non-empty metadata of a table as label/value pairs
+// Attributes follow the built-in fields, sorted by key
+func tableProperties(t *model.Table) [][2]string {
+	var props [][2]string
+	add := func(label, value string) {
+		if value != "" {
+			props = append(props, [2]string{label, value})
+		}
+	}
+	add("Keys", t.Keys)
+	add("Path", t.Path)
+	add("Engine", t.Engine)
+	add("Source", t.Source)
+	add("Script", joinTag(t.ScriptFile, t.ScriptTag))
+	add("Description file", joinTag(t.DescFile, t.DescTag))
+	add("Log file", t.LogFile)
+	add("Tags", strings.Join(t.Tags, ", "))
+	add("Records", strconv.Itoa(len(t.Records)))
+	if !t.ModifyAt.IsZero() {
+		add("Modified", t.ModifyAt.Format("2006-01-02"))
+	}
+	for _, k := range sortedKeys(t.Attributes) {
+		add(k, t.Attributes[k])
+	}
+	return props
+}
+
+// recordNote returns the description of a record followed by its tags and attributes
+func recordNote(r *model.Record) string {
+	parts := []string{}
+	if r.Description != "" {
+		parts = append(parts, r.Description)
+	}
+	if len(r.Tags) > 0 {
+		parts = append(parts, "tags: "+strings.Join(r.Tags, ", "))
+	}
+	for _, k := range sortedKeys(r.Attributes) {
+		parts = append(parts, k+"="+r.Attributes[k])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// joinTag appends a script tag to a file name, as file#tag
+func joinTag(file, tag string) string {
+	if file == "" || tag == "" {
+		return file
+	}
+	return file + "#" + tag
+}
+
+// title returns the catalog title or the default one
+func title(c *Catalog) string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return DefaultTitle
+}
+
+// mdText escapes characters that start Markdown markup
+var mdText = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "<", "&lt;").Replace
+
+// mdCell escapes a value for a Markdown table cell, which must stay on one line
+func mdCell(s string) string {
+	s = strings.ReplaceAll(mdText(s), "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,244 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"srdm/internal/datapackage"
+	"srdm/internal/model"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("rocrate", roCrate{}, "ro-crate-metadata.json")
+}
+
+// roCrate writes the ro-crate-metadata.json of an RO-Crate 1.1
+// The root dataset takes its metadata from table attributes (see datasetAttributes),
+// each table is a data entity and each record a variable measured by it
+type roCrate struct{}
+
+// roCrateContext is the JSON-LD context of RO-Crate 1.1
+const roCrateContext = "https://w3id.org/ro/crate/1.1/context"
+
+// entity is a JSON-LD node of the crate graph
+type entity map[string]any
+
+// Validate reports the properties RO-Crate requires on the root dataset
+func (roCrate) Validate(c *Catalog) []Problem {
+	d, err := newDataset("an RO-Crate", c)
+	if err != nil {
+		return nil // Export reports the selection
+	}
+	var problems []Problem
+	if d.Title == "" {
+		problems = append(problems, d.missing("title", "or use --title"))
+	}
+	if d.Description == "" {
+		problems = append(problems, d.missing("description", "or describe the table"))
+	}
+	if d.License == "" {
+		problems = append(problems, d.missing("license", "(an SPDX identifier such as CC-BY-4.0 or a URL)"))
+	}
+	if d.Published == "" {
+		problems = append(problems, d.missing("published", ""))
+	}
+	return problems
+}
+
+// Export writes the crate metadata file
+func (roCrate) Export(w io.Writer, c *Catalog) error {
+	d, err := newDataset("an RO-Crate", c)
+	if err != nil {
+		return err
+	}
+
+	root := entity{
+		"@id":           "./",
+		"@type":         "Dataset",
+		"name":          d.Title,
+		"description":   d.Description,
+		"datePublished": d.Published,
+	}
+	graph := []entity{
+		{
+			"@id":        "ro-crate-metadata.json",
+			"@type":      "CreativeWork",
+			"conformsTo": ref("https://w3id.org/ro/crate/1.1"),
+			"about":      ref("./"),
+		},
+		root,
+	}
+	if d.License != "" {
+		url := licenseURL(d.License)
+		root["license"] = ref(url)
+		graph = append(graph, entity{"@id": url, "@type": "CreativeWork", "name": d.License})
+	}
+	if d.DOI != "" {
+		root["identifier"] = "https://doi.org/" + d.DOI
+	}
+	// Local ids are made unique, so that distinct entities are not merged
+	ids := localIDs{}
+	if d.Publisher != "" {
+		id := ids.named(d.Publisher, "publisher")
+		root["publisher"] = ref(id)
+		graph = append(graph, entity{"@id": id, "@type": "Organization", "name": d.Publisher})
+	}
+	if len(d.Keywords) > 0 {
+		root["keywords"] = d.Keywords
+	}
+	var creators []entity
+	for i, p := range d.Creators {
+		var id string
+		if p.ORCID != "" {
+			// The same ORCID listed twice is one person
+			if id = "https://orcid.org/" + p.ORCID; ids[id] {
+				continue
+			}
+			ids[id] = true
+		} else {
+			id = ids.named(p.Name, fmt.Sprintf("creator-%d", i+1))
+		}
+		creators = append(creators, ref(id))
+		graph = append(graph, entity{"@id": id, "@type": "Person", "name": p.Name})
+	}
+	if len(creators) > 0 {
+		root["creator"] = creators
+	}
+
+	// Tables sharing a data file, such as several tables of one SQLite database,
+	// are merged into the one entity of that file
+	var parts []entity
+	files := map[string]*filePart{}
+	for i := range c.Tables {
+		t := &c.Tables[i]
+		var id, kind string
+		switch {
+		case t.Path == "":
+			id, kind = ids.unique(anchor(t.FullName())), "Dataset"
+		case strings.Contains(t.Path, "://"):
+			id, kind = t.Path, "File"
+		default:
+			id, kind = datapackage.RelativePath(t.Path, c.Dir, true), "File"
+		}
+		f := files[id]
+		if f == nil {
+			f = &filePart{entity: entity{"@id": id, "@type": kind}}
+			if mt := mediaType(t.Path); mt != "" {
+				f.entity["encodingFormat"] = mt
+			}
+			files[id] = f
+			parts = append(parts, ref(id))
+			graph = append(graph, f.entity)
+		}
+		f.add(t)
+
+		for j := range t.Records {
+			r := &t.Records[j]
+			vid := ids.unique(anchor(r.FullName()))
+			f.variables = append(f.variables, ref(vid))
+			v := entity{"@id": vid, "@type": "PropertyValue", "name": r.Name}
+			if desc := joinNonEmpty(". ", r.Label, r.Description); desc != "" {
+				v["description"] = desc
+			}
+			if r.Type != "" {
+				v["valueReference"] = r.Type
+			}
+			if unit := r.Attributes["unit"]; unit != "" {
+				v["unitText"] = unit
+			}
+			graph = append(graph, v)
+		}
+	}
+	for _, f := range files {
+		f.finish()
+	}
+	if len(parts) > 0 {
+		root["hasPart"] = parts
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(entity{"@context": roCrateContext, "@graph": graph}); err != nil {
+		return fmt.Errorf("failed to encode RO-Crate: %w", err)
+	}
+	return nil
+}
+
+// filePart collects the tables described by one data entity
+type filePart struct {
+	entity       entity
+	names        []string
+	descriptions []string
+	keywords     []string
+	modified     time.Time
+	variables    []entity
+}
+
+// add merges table t into the entity
+func (f *filePart) add(t *model.Table) {
+	f.names = append(f.names, t.FullName())
+	if t.Description != "" {
+		f.descriptions = append(f.descriptions, t.Description)
+	}
+	for _, tag := range t.Tags {
+		if !slices.Contains(f.keywords, tag) {
+			f.keywords = append(f.keywords, tag)
+		}
+	}
+	if t.ModifyAt.After(f.modified) {
+		f.modified = t.ModifyAt
+	}
+}
+
+// finish sets the merged properties on the entity
+func (f *filePart) finish() {
+	f.entity["name"] = strings.Join(f.names, ", ")
+	if len(f.descriptions) > 0 {
+		f.entity["description"] = strings.Join(f.descriptions, "\n\n")
+	}
+	if !f.modified.IsZero() {
+		f.entity["dateModified"] = f.modified.Format("2006-01-02")
+	}
+	if len(f.keywords) > 0 {
+		f.entity["keywords"] = f.keywords
+	}
+	if len(f.variables) > 0 {
+		f.entity["variableMeasured"] = f.variables
+	}
+}
+
+// ref returns a JSON-LD reference to another entity
+func ref(id string) entity {
+	return entity{"@id": id}
+}
+
+// slug turns a name into a local identifier
+func slug(name string) string {
+	return strings.Trim(slugRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// localIDs holds the local @ids handed out in a crate
+type localIDs map[string]bool
+
+// named returns the local @id of a named entity, built from fallback when the
+// name has no ASCII letters or digits to slug, as in "李四"
+func (ids localIDs) named(name, fallback string) string {
+	if s := slug(name); s != "" {
+		return ids.unique(s)
+	}
+	return ids.unique(fallback)
+}
+
+// unique returns "#" + base, with the first free -2, -3... suffix when the id is
+// taken, as by two people of the same name, and marks it as taken
+func (ids localIDs) unique(base string) string {
+	id := "#" + base
+	for n := 2; ids[id]; n++ {
+		id = fmt.Sprintf("#%s-%d", base, n)
+	}
+	ids[id] = true
+	return id
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Fields of tables and records whose files are checksummed
+const (
+	FileFieldPath   = "path"   // Table.Path
+	FileFieldSource = "source" // Record.Source
+)
+
+// Checksum records the state of a referenced file when it was registered
+type Checksum struct {
+	Name      string    `json:"name"`       // Full name of the table or record
+	Field     string    `json:"field"`      // Field holding the file path, e.g. path or source
+	Path      string    `json:"path"`       // File path at registration time
+	Size      int64     `json:"size"`       // File size in bytes
+	ModTime   time.Time `json:"mtime"`      // File modification time
+	SHA256    string    `json:"sha256"`     // Hex-encoded SHA-256 of the content
+	CheckedAt time.Time `json:"checked_at"` // When the checksum was recorded
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// History actions recorded for every repository mutation
+const (
+	ActionInsert  = "insert"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRevert  = "revert"
+	ActionRename  = "rename"
+	ActionRestore = "restore"
+)
+
+// HistoryEntry is one append-only change of a table or record
+// Before is null for inserts, After is null for deletes
+type HistoryEntry struct {
+	ID        int64           `json:"id"`         // Global sequence number
+	Name      string          `json:"name"`       // Full name of the changed table or record
+	Version   int             `json:"version"`    // Per-name version, starting at 1
+	Action    string          `json:"action"`     // insert, update, delete, revert, rename or restore
+	Before    json.RawMessage `json:"before"`     // JSON state before the change
+	After     json.RawMessage `json:"after"`      // JSON state after the change
+	User      string          `json:"user"`       // OS user who made the change
+	ChangedAt time.Time       `json:"changed_at"` // Time of the change
+}
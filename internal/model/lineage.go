@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Lineage relations between a derived item and where it came from
+const (
+	RelationDerivedFrom      = "derived_from"       // Upstream is another table or record
+	RelationProducedByScript = "produced_by_script" // Upstream is the script file that produced the item
+)
+
+// LineageEdge links a derived table or record to one of its upstreams
+type LineageEdge struct {
+	Name     string    `json:"name"`      // Full name of the derived table or record
+	Upstream string    `json:"upstream"`  // Full name of the upstream item, or a script path
+	Relation string    `json:"relation"`  // derived_from or produced_by_script
+	CreateAt time.Time `json:"create_at"` // Creation time
+}
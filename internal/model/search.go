@@ -0,0 +1,9 @@
+package model
+
+// TextHit is one ranked result of a full-text search
+type TextHit struct {
+	Name    string  `json:"name"`    // Full name of the matching table or record
+	Kind    string  `json:"kind"`    // "table" or "record"
+	Snippet string  `json:"snippet"` // Matching text with the hits highlighted
+	Rank    float64 `json:"rank"`    // BM25 score, lower is better
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Kinds of trashed items
+const (
+	KindTable  = "table"
+	KindRecord = "record"
+)
+
+// TrashItem is a deleted table or record kept in the trash until it is restored or purged
+type TrashItem struct {
+	ID        int64     `json:"id"`         // Trash entry number, used to pick among items of the same name
+	Name      string    `json:"name"`       // Full name of the deleted table or record
+	Kind      string    `json:"kind"`       // table or record
+	Records   int       `json:"records"`    // Number of records deleted together with a table
+	User      string    `json:"user"`       // OS user who deleted the item
+	DeletedAt time.Time `json:"deleted_at"` // Time of the deletion
+}
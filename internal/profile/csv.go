@@ -0,0 +1,277 @@
+package profile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header modes of delimited files
+const (
+	HeaderAuto = "auto"
+	HeaderYes  = "yes"
+	HeaderNo   = "no"
+)
+
+// sampleSize is how much of a delimited file is inspected to detect its layout
+const sampleSize = 64 << 10
+
+// utf8BOM is skipped at the start of a file
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// delimiters are the candidates tried by delimiter detection
+var delimiters = []rune{',', '\t', ';', '|'}
+
+// missingValues are the cell values counted as missing
+var missingValues = map[string]bool{
+	"": true, "NA": true, "N/A": true, "NULL": true, "null": true, "NaN": true, "nan": true, ".": true,
+}
+
+// dateLayouts are the formats recognized as dates
+var dateLayouts = []string{
+	"2006-01-02", "2006/01/02", "2006-01-02 15:04:05", "2006-01-02T15:04:05", time.RFC3339,
+}
+
+// CSV profiles a delimited text file in a single streaming pass
+// delimiter 0 detects the delimiter; header is HeaderAuto, HeaderYes or HeaderNo
+// Memory use is bounded per column, independent of the number of rows
+func CSV(path string, delimiter rune, header string) ([]Column, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data file: %w", err)
+	}
+	defer file.Close()
+
+	in := bufio.NewReaderSize(file, sampleSize)
+	sample, err := in.Peek(sampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	if err == bufio.ErrBufferFull {
+		// Drop the partial line cut off by the sample size
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+	sample = bytes.TrimPrefix(sample, utf8BOM)
+	if delimiter == 0 {
+		delimiter = detectDelimiter(sample)
+	}
+	hasHeader := header == HeaderYes
+	if header == "" || header == HeaderAuto {
+		hasHeader = detectHeader(sample, delimiter)
+	}
+
+	// Skip a byte order mark
+	if bom, _ := in.Peek(3); bytes.Equal(bom, utf8BOM) {
+		in.Discard(3)
+	}
+	r := csv.NewReader(in)
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+
+	var stats []*columnStats
+	var names []string
+	rows := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data file: %w", err)
+		}
+		if hasHeader && names == nil {
+			for i, name := range record {
+				if name = strings.TrimSpace(name); name == "" {
+					name = fmt.Sprintf("column%d", i+1)
+				}
+				names = append(names, name)
+				stats = append(stats, newColumnStats(0))
+			}
+			continue
+		}
+
+		// Columns first seen on this row were missing on all earlier rows
+		for len(stats) < len(record) {
+			stats = append(stats, newColumnStats(rows))
+		}
+		for i, s := range stats {
+			value := ""
+			if i < len(record) {
+				value = strings.TrimSpace(record[i])
+			}
+			s.add(value)
+		}
+		rows++
+	}
+
+	columns := make([]Column, len(stats))
+	for i, s := range stats {
+		name := fmt.Sprintf("column%d", i+1)
+		if i < len(names) {
+			name = names[i]
+		}
+		columns[i] = Column{
+			Name:         name,
+			Type:         s.inferredType(),
+			Number:       rows,
+			MissNumber:   s.missing,
+			UniqueNumber: s.distinct.Count(),
+		}
+	}
+	return columns, nil
+}
+
+// columnStats accumulates the statistics of one column
+type columnStats struct {
+	missing             int
+	values              int
+	ints, floats, dates int
+	distinct            *distinctCounter
+}
+
+// newColumnStats starts a column that was missing on the first missing rows
+func newColumnStats(missing int) *columnStats {
+	return &columnStats{missing: missing, distinct: newDistinctCounter()}
+}
+
+func (s *columnStats) add(value string) {
+	if missingValues[value] {
+		s.missing++
+		return
+	}
+	s.values++
+	s.distinct.Add(value)
+	switch valueType(value) {
+	case TypeInt:
+		s.ints++
+	case TypeFloat:
+		s.floats++
+	case TypeDate:
+		s.dates++
+	}
+}
+
+// inferredType is the narrowest type all non-missing values fit
+func (s *columnStats) inferredType() string {
+	switch {
+	case s.values == 0:
+		return TypeString
+	case s.ints == s.values:
+		return TypeInt
+	case s.ints+s.floats == s.values:
+		return TypeFloat
+	case s.dates == s.values:
+		return TypeDate
+	}
+	return TypeString
+}
+
+// valueType classifies a single non-missing value
+func valueType(value string) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return TypeInt
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return TypeFloat
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return TypeDate
+		}
+	}
+	return TypeString
+}
+
+// sampleRows parses the complete lines of a sample with the given delimiter
+func sampleRows(sample []byte, delimiter rune) [][]string {
+	r := csv.NewReader(bytes.NewReader(sample))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var rows [][]string
+	for len(rows) < 100 {
+		record, err := r.Read()
+		if err != nil {
+			break
+		}
+		rows = append(rows, record)
+	}
+	return rows
+}
+
+// detectDelimiter picks the candidate splitting the sample into the most columns
+// while keeping the column count consistent across rows
+func detectDelimiter(sample []byte) rune {
+	best, bestScore := ',', 0
+	for _, d := range delimiters {
+		rows := sampleRows(sample, d)
+		if len(rows) == 0 {
+			continue
+		}
+		width := len(rows[0])
+		if width < 2 {
+			continue
+		}
+		consistent := 0
+		for _, row := range rows {
+			if len(row) == width {
+				consistent++
+			}
+		}
+		// Prefer the share of rows with the same width, then more columns
+		if score := consistent*1000/len(rows)*1000 + width; score > bestScore {
+			best, bestScore = d, score
+		}
+	}
+	return best
+}
+
+// detectHeader reports whether the first row looks like column names:
+// no missing, duplicate, numeric or date cells, while some column below it
+// holds numbers or dates, or the first row is the only one
+func detectHeader(sample []byte, delimiter rune) bool {
+	rows := sampleRows(sample, delimiter)
+	if len(rows) == 0 {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, cell := range rows[0] {
+		cell = strings.TrimSpace(cell)
+		if missingValues[cell] || seen[cell] || valueType(cell) != TypeString {
+			return false
+		}
+		seen[cell] = true
+	}
+	if len(rows) == 1 {
+		return true
+	}
+
+	for col := range rows[0] {
+		for _, row := range rows[1:] {
+			if col < len(row) && !missingValues[strings.TrimSpace(row[col])] &&
+				valueType(strings.TrimSpace(row[col])) != TypeString {
+				return true
+			}
+		}
+	}
+	// All text: a header is likely if no first-row cell repeats in its column
+	for col, cell := range rows[0] {
+		for _, row := range rows[1:] {
+			if col < len(row) && strings.TrimSpace(row[col]) == strings.TrimSpace(cell) {
+				return false
+			}
+		}
+	}
+	return true
+}
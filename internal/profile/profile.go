@@ -0,0 +1,61 @@
+// Package profile computes per-column statistics of the data files tables point at
+//
+// Each supported engine reads the data once and reports, for every column,
+// the inferred type and the row, missing and distinct counts stored on records.
+package profile
+
+import (
+	"fmt"
+	"srdm/internal/model"
+	"strings"
+)
+
+// Inferred column types
+const (
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeDate   = "date"
+	TypeString = "string"
+	TypeBlob   = "blob"
+)
+
+// Column holds the statistics of one column of a data file
+type Column struct {
+	Name         string
+	Type         string
+	Number       int // Number of rows
+	MissNumber   int // Number of missing values
+	UniqueNumber int // Number of distinct non-missing values
+}
+
+// Options adjusts how a data file is read
+type Options struct {
+	SourceTable string // Table inside a SQLite file, defaults to the table's name
+	Delimiter   rune   // Delimiter of a CSV/TSV file, 0 to detect it
+	Header      string // Header row of a CSV/TSV file: HeaderAuto, HeaderYes or HeaderNo
+}
+
+// Table profiles the data file of t according to its engine
+func Table(t *model.Table, opts Options) ([]Column, error) {
+	if t.Path == "" {
+		return nil, fmt.Errorf("table %s has no data path", t.FullName())
+	}
+
+	switch strings.ToLower(t.Engine) {
+	case "sqlite3", "sqlite":
+		source := opts.SourceTable
+		if source == "" {
+			source = t.Name
+		}
+		return SQLite(t.Path, source)
+	case "csv":
+		return CSV(t.Path, opts.Delimiter, opts.Header)
+	case "tsv":
+		delimiter := opts.Delimiter
+		if delimiter == 0 {
+			delimiter = '\t'
+		}
+		return CSV(t.Path, delimiter, opts.Header)
+	}
+	return nil, fmt.Errorf("profiling is not supported for engine %q", t.Engine)
+}
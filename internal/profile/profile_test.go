@@ -0,0 +1,126 @@
+package profile
+
+import (
+	"database/sql"
+	"math"
+	"os"
+	"path/filepath"
+	"srdm/internal/model"
+	"strconv"
+	"testing"
+)
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.sqlite")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+	CREATE TABLE people (id INTEGER, name TEXT, score REAL, born DATE, extra);
+	INSERT INTO people VALUES (1, 'a', 1.5, '2000-01-01', 1);
+	INSERT INTO people VALUES (2, 'b', NULL, '2001-01-01', 2.5);
+	INSERT INTO people VALUES (3, 'b', 2.0, NULL, NULL);
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := Table(&model.Table{Database: "db", Name: "people", Path: path, Engine: "SQLite3"}, Options{})
+	if err != nil {
+		t.Fatalf("Profile failed: %v", err)
+	}
+
+	want := []Column{
+		{"id", TypeInt, 3, 0, 3},
+		{"name", TypeString, 3, 0, 2},
+		{"score", TypeFloat, 3, 1, 2},
+		{"born", TypeDate, 3, 1, 2},
+		{"extra", TypeFloat, 3, 1, 2},
+	}
+	if len(columns) != len(want) {
+		t.Fatalf("Expected %d columns, got %+v", len(want), columns)
+	}
+	for i, c := range columns {
+		if c != want[i] {
+			t.Errorf("Column %d: expected %+v, got %+v", i, want[i], c)
+		}
+	}
+
+	if _, err := SQLite(path, "missing"); err == nil {
+		t.Error("Expected error for a missing table")
+	}
+	if _, err := Table(&model.Table{Name: "x", Path: path, Engine: "Parquet"}, Options{}); err == nil {
+		t.Error("Expected error for an unsupported engine")
+	}
+}
+
+func TestCSV(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.csv")
+	data := "\xef\xbb\xbfid;score;born;name\n" +
+		"1;1.5;2000-01-01;a\n" +
+		"2;NA;2001-01-01;\"b;c\"\n" +
+		"3;2;;b\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := Table(&model.Table{Name: "data", Path: path, Engine: "CSV"}, Options{})
+	if err != nil {
+		t.Fatalf("Profile failed: %v", err)
+	}
+	want := []Column{
+		{"id", TypeInt, 3, 0, 3},
+		{"score", TypeFloat, 3, 1, 2},
+		{"born", TypeDate, 3, 1, 2},
+		{"name", TypeString, 3, 0, 3},
+	}
+	if len(columns) != len(want) {
+		t.Fatalf("Expected %d columns, got %+v", len(want), columns)
+	}
+	for i, c := range columns {
+		if c != want[i] {
+			t.Errorf("Column %d: expected %+v, got %+v", i, want[i], c)
+		}
+	}
+
+	// Without a header the first row is data
+	path = filepath.Join(dir, "data.tsv")
+	if err := os.WriteFile(path, []byte("1\tx\n2\ty\n2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	columns, err = Table(&model.Table{Name: "data", Path: path, Engine: "TSV"}, Options{})
+	if err != nil {
+		t.Fatalf("Profile failed: %v", err)
+	}
+	if len(columns) != 2 || columns[0] != (Column{"column1", TypeInt, 3, 0, 2}) ||
+		columns[1] != (Column{"column2", TypeString, 3, 1, 2}) {
+		t.Errorf("Unexpected headerless profile: %+v", columns)
+	}
+}
+
+func TestDistinctCounter(t *testing.T) {
+	c := newDistinctCounter()
+	for i := 0; i < 1000; i++ {
+		c.Add(strconv.Itoa(i % 100))
+	}
+	if got := c.Count(); got != 100 {
+		t.Errorf("Expected exact count 100, got %d", got)
+	}
+
+	// Past the exact limit the estimate must stay within a few percent
+	c = newDistinctCounter()
+	const n = 200000
+	for i := 0; i < n; i++ {
+		c.Add(strconv.Itoa(i))
+		c.Add(strconv.Itoa(i))
+	}
+	if c.exact != nil {
+		t.Error("Expected the counter to switch to HyperLogLog")
+	}
+	if got := c.Count(); math.Abs(float64(got-n))/n > 0.03 {
+		t.Errorf("Estimate %d too far from %d", got, n)
+	}
+}
@@ -0,0 +1,153 @@
+package profile
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLite profiles table in the SQLite database file at path
+// The file is opened read-only; missing values are NULLs
+func SQLite(path, table string) ([]Column, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open data file: %w", err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+url.PathEscape(path)+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data file: %w", err)
+	}
+	defer db.Close()
+
+	var found int
+	if err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view') AND name = ?", table,
+	).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("table %s not found in %s", table, path)
+	}
+
+	columns, err := sqliteColumns(db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	// Count rows, non-NULL and distinct values of every column in one pass
+	exprs := []string{"COUNT(*)"}
+	for _, c := range columns {
+		q := quoteIdent(c.Name)
+		exprs = append(exprs, "COUNT("+q+")", "COUNT(DISTINCT "+q+")")
+	}
+	counts := make([]int, len(exprs))
+	dest := make([]any, len(exprs))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := db.QueryRow("SELECT " + strings.Join(exprs, ", ") + " FROM " + quoteIdent(table)).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to profile %s: %w", table, err)
+	}
+	for i := range columns {
+		columns[i].Number = counts[0]
+		columns[i].MissNumber = counts[0] - counts[1+2*i]
+		columns[i].UniqueNumber = counts[2+2*i]
+	}
+
+	for i := range columns {
+		if columns[i].Type != "" {
+			continue
+		}
+		if columns[i].Type, err = storedType(db, table, columns[i].Name); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
+
+// sqliteColumns lists the columns of table with the type implied by their declaration
+// Columns declared without a recognizable type are left with an empty Type
+func sqliteColumns(db *sql.DB, table string) ([]Column, error) {
+	rows, err := db.Query("SELECT name, type FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []Column
+	for rows.Next() {
+		var name, declared string
+		if err := rows.Scan(&name, &declared); err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		columns = append(columns, Column{Name: name, Type: declaredType(declared)})
+	}
+	return columns, rows.Err()
+}
+
+// declaredType maps a declared column type to a profile type
+// following SQLite's type affinity rules, with dates recognized by name
+func declaredType(declared string) string {
+	d := strings.ToUpper(declared)
+	switch {
+	case d == "":
+		return ""
+	case strings.Contains(d, "INT"):
+		return TypeInt
+	case strings.Contains(d, "DATE"), strings.Contains(d, "TIME"):
+		return TypeDate
+	case strings.Contains(d, "CHAR"), strings.Contains(d, "CLOB"), strings.Contains(d, "TEXT"):
+		return TypeString
+	case strings.Contains(d, "BLOB"):
+		return TypeBlob
+	case strings.Contains(d, "REAL"), strings.Contains(d, "FLOA"), strings.Contains(d, "DOUB"):
+		return TypeFloat
+	}
+	// NUMERIC affinity and anything else: decide from the stored values
+	return ""
+}
+
+// storedType infers a column type from the storage classes of its values
+func storedType(db *sql.DB, table, column string) (string, error) {
+	q := quoteIdent(column)
+	rows, err := db.Query("SELECT DISTINCT typeof(" + q + ") FROM " + quoteIdent(table) + " WHERE " + q + " IS NOT NULL")
+	if err != nil {
+		return "", fmt.Errorf("failed to infer type of %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	classes := map[string]bool{}
+	for rows.Next() {
+		var class string
+		if err := rows.Scan(&class); err != nil {
+			return "", err
+		}
+		classes[class] = true
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	switch {
+	case classes["text"]:
+		return TypeString, nil
+	case classes["blob"]:
+		return TypeBlob, nil
+	case classes["real"]:
+		return TypeFloat, nil
+	case classes["integer"]:
+		return TypeInt, nil
+	}
+	return TypeString, nil
+}
+
+// quoteIdent quotes an SQL identifier
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,319 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the value type of a filterable field
+type Kind int
+
+const (
+	Text Kind = iota
+	Int
+	Time
+	Tag
+	Attr
+)
+
+// attrPrefix introduces a custom attribute in a field name, as in attr.license
+const attrPrefix = "attr."
+
+// Field maps a filter field name to an SQL expression
+type Field struct {
+	Expr string // SQL expression, may reference the schema table
+	Kind Kind
+}
+
+// Schema describes the fields that can be filtered and sorted for one table
+type Schema struct {
+	Table  string           // Name of the SQL table
+	Fields map[string]Field // Keyed by lower-case field name
+}
+
+// Filter is a compiled, parameterized SQL condition
+type Filter struct {
+	Where string
+	Args  []any
+}
+
+// databaseExpr extracts the database part of a full name
+const databaseExpr = "substr(%[1]s.name, 1, instr(%[1]s.name, ':') - 1)"
+
+// tableExpr extracts the table part of a db:table:record name
+const tableExpr = "substr(substr(data_record.name, instr(data_record.name, ':') + 1), 1, " +
+	"instr(substr(data_record.name, instr(data_record.name, ':') + 1), ':') - 1)"
+
+// Records is the schema of data_record
+var Records = Schema{
+	Table: "data_record",
+	Fields: withAliases(map[string]Field{
+		"name":         {"data_record.name", Text},
+		"database":     {fmt.Sprintf(databaseExpr, "data_record"), Text},
+		"table":        {tableExpr, Text},
+		"type":         {"data_record.type", Text},
+		"source":       {"data_record.source", Text},
+		"label":        {"data_record.label", Text},
+		"description":  {"data_record.description", Text},
+		"number":       {"data_record.number", Int},
+		"missnumber":   {"data_record.missNumber", Int},
+		"uniquenumber": {"data_record.uniqueNumber", Int},
+		"script_file":  {"data_record.script_file", Text},
+		"script_tag":   {"data_record.script_tag", Text},
+		"desc_file":    {"data_record.desc_file", Text},
+		"desc_tag":     {"data_record.desc_tag", Text},
+		"log_file":     {"data_record.log_file", Text},
+		"created":      {"data_record.create_at", Time},
+		"modified":     {"data_record.modify_at", Time},
+		"tag":          {"data_record.name", Tag},
+	}),
+}
+
+// Tables is the schema of data_table
+var Tables = Schema{
+	Table: "data_table",
+	Fields: withAliases(map[string]Field{
+		"name":        {"data_table.name", Text},
+		"database":    {fmt.Sprintf(databaseExpr, "data_table"), Text},
+		"keys":        {"data_table.keys", Text},
+		"path":        {"data_table.path", Text},
+		"engine":      {"data_table.engine", Text},
+		"source":      {"data_table.source", Text},
+		"description": {"data_table.description", Text},
+		"script_file": {"data_table.script_file", Text},
+		"script_tag":  {"data_table.script_tag", Text},
+		"desc_file":   {"data_table.desc_file", Text},
+		"desc_tag":    {"data_table.desc_tag", Text},
+		"log_file":    {"data_table.log_file", Text},
+		"created":     {"data_table.create_at", Time},
+		"modified":    {"data_table.modify_at", Time},
+		"tag":         {"data_table.name", Tag},
+	}),
+}
+
+// withAliases adds the column names used in JSON output as alternative field names
+func withAliases(fields map[string]Field) map[string]Field {
+	if f, ok := fields["created"]; ok {
+		fields["create_at"] = f
+	}
+	if f, ok := fields["modified"]; ok {
+		fields["modify_at"] = f
+	}
+	return fields
+}
+
+// Compile parses a filter expression and compiles it against the schema
+func Compile(expr string, s Schema) (*Filter, error) {
+	node, err := Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	b := &builder{}
+	if err := node.compile(s, b); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return &Filter{Where: b.sb.String(), Args: b.args}, nil
+}
+
+// OrderBy compiles a sort specification such as "-modified,name"
+// into an SQL ORDER BY list; a leading - sorts descending
+func (s Schema) OrderBy(spec string) (string, error) {
+	var parts []string
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		dir := "ASC"
+		if strings.HasPrefix(item, "-") {
+			dir = "DESC"
+			item = item[1:]
+		} else {
+			item = strings.TrimPrefix(item, "+")
+		}
+		f, err := s.lookup(item)
+		if err != nil {
+			return "", err
+		}
+		if f.Kind == Tag || f.Kind == Attr {
+			return "", fmt.Errorf("cannot sort by %s", item)
+		}
+		parts = append(parts, f.Expr+" "+dir)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// lookup resolves a field name; attr.KEY refers to the custom attribute KEY
+// of the schema's rows, whose name column is the Expr of the returned field
+func (s Schema) lookup(name string) (Field, error) {
+	if key, ok := attrKey(name); ok {
+		if key == "" {
+			return Field{}, fmt.Errorf("missing attribute name in %q", name)
+		}
+		return Field{s.Table + ".name", Attr}, nil
+	}
+	f, ok := s.Fields[strings.ToLower(name)]
+	if !ok {
+		return Field{}, fmt.Errorf("unknown field %q", name)
+	}
+	return f, nil
+}
+
+// attrKey returns the attribute key of an attr.KEY field name
+// The key keeps its case; the prefix is case-insensitive like other field names
+func attrKey(name string) (string, bool) {
+	if len(name) < len(attrPrefix) || !strings.EqualFold(name[:len(attrPrefix)], attrPrefix) {
+		return "", false
+	}
+	return name[len(attrPrefix):], true
+}
+
+// builder accumulates the SQL text and its arguments
+type builder struct {
+	sb   strings.Builder
+	args []any
+}
+
+func (n *And) compile(s Schema, b *builder) error {
+	return compileBinary(s, b, n.Left, "AND", n.Right)
+}
+
+func (n *Or) compile(s Schema, b *builder) error {
+	return compileBinary(s, b, n.Left, "OR", n.Right)
+}
+
+func compileBinary(s Schema, b *builder, left Node, op string, right Node) error {
+	b.sb.WriteString("(")
+	if err := left.compile(s, b); err != nil {
+		return err
+	}
+	b.sb.WriteString(" " + op + " ")
+	if err := right.compile(s, b); err != nil {
+		return err
+	}
+	b.sb.WriteString(")")
+	return nil
+}
+
+func (n *Not) compile(s Schema, b *builder) error {
+	b.sb.WriteString("NOT (")
+	if err := n.Expr.compile(s, b); err != nil {
+		return err
+	}
+	b.sb.WriteString(")")
+	return nil
+}
+
+func (n *Comparison) compile(s Schema, b *builder) error {
+	f, err := s.lookup(n.Field)
+	if err != nil {
+		return err
+	}
+
+	if f.Kind == Attr {
+		return n.compileAttr(f, b)
+	}
+
+	switch n.Op {
+	case "~", "!~":
+		not := ""
+		if n.Op == "!~" {
+			not = "NOT "
+		}
+		pattern := "%" + escapeLike(n.Value) + "%"
+		if f.Kind == Tag {
+			b.sb.WriteString(not + "EXISTS (SELECT 1 FROM data_tag WHERE data_tag.name = " + f.Expr +
+				" AND data_tag.tag LIKE ? ESCAPE '\\')")
+		} else {
+			b.sb.WriteString("COALESCE(" + f.Expr + ", '') " + not + "LIKE ? ESCAPE '\\'")
+		}
+		b.args = append(b.args, pattern)
+		return nil
+	}
+
+	switch f.Kind {
+	case Tag:
+		switch n.Op {
+		case "=":
+			b.sb.WriteString("EXISTS (SELECT 1 FROM data_tag WHERE data_tag.name = " + f.Expr + " AND data_tag.tag = ?)")
+		case "!=":
+			b.sb.WriteString("NOT EXISTS (SELECT 1 FROM data_tag WHERE data_tag.name = " + f.Expr + " AND data_tag.tag = ?)")
+		default:
+			return fmt.Errorf("operator %s not supported for %s", n.Op, n.Field)
+		}
+		b.args = append(b.args, n.Value)
+	case Int:
+		v, err := strconv.ParseInt(n.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s expects an integer, got %q", n.Field, n.Value)
+		}
+		b.sb.WriteString(f.Expr + " " + n.Op + " ?")
+		b.args = append(b.args, v)
+	case Time:
+		v, err := parseTime(n.Value)
+		if err != nil {
+			return fmt.Errorf("%s expects a date (YYYY-MM-DD) or RFC3339 time, got %q", n.Field, n.Value)
+		}
+		// Timestamps are stored as text with or without a zone suffix; both sides are
+		// normalised to UTC, reading values without a zone as local time ('utc' leaves
+		// values with a zone as they are)
+		b.sb.WriteString("datetime(" + f.Expr + ", 'utc') " + n.Op + " datetime(?, 'utc')")
+		b.args = append(b.args, v.Format(time.RFC3339))
+	default:
+		b.sb.WriteString(f.Expr + " " + n.Op + " ?")
+		b.args = append(b.args, n.Value)
+	}
+	return nil
+}
+
+// compileAttr tests whether the item has the attribute with a matching value
+// Negated operators also match items without the attribute.
+// Ordering operators compare numerically when the value is a number
+func (n *Comparison) compileAttr(f Field, b *builder) error {
+	key, _ := attrKey(n.Field)
+	cond, value := "data_attribute.value = ?", any(n.Value)
+	exists := "EXISTS"
+	switch n.Op {
+	case "=":
+	case "!=":
+		exists = "NOT EXISTS"
+	case "~", "!~":
+		cond, value = "data_attribute.value LIKE ? ESCAPE '\\'", "%"+escapeLike(n.Value)+"%"
+		if n.Op == "!~" {
+			exists = "NOT EXISTS"
+		}
+	default:
+		cond = "data_attribute.value " + n.Op + " ?"
+		if v, err := strconv.ParseFloat(n.Value, 64); err == nil {
+			cond, value = "CAST(data_attribute.value AS REAL) "+n.Op+" ?", v
+		}
+	}
+	b.sb.WriteString(exists + " (SELECT 1 FROM data_attribute WHERE data_attribute.name = " + f.Expr +
+		" AND data_attribute.key = ? AND " + cond + ")")
+	b.args = append(b.args, key, value)
+	return nil
+}
+
+// parseTime accepts a date, a date with time, or an RFC3339 timestamp
+// Dates without a zone are interpreted in local time, like the stored timestamps
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// LikePrefix returns a pattern for LIKE ... ESCAPE '\' matching the values that
+// start with prefix, whose wildcards match literally
+func LikePrefix(prefix string) string {
+	return escapeLike(prefix) + "%"
+}
+
+// escapeLike escapes LIKE wildcards so values match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
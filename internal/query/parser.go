@@ -0,0 +1,236 @@
+// Package query implements the small filter language of `srdm search --where`
+//
+// An expression combines comparisons with AND, OR, NOT and parentheses:
+//
+//	type=fastq AND number>1000 AND modified>2025-01-01 AND label~control
+//	(tag=cleaned OR tag=raw) AND NOT description~draft
+//	attr.license=CC-BY AND attr.year>=2020
+//
+// Operators are = != > >= < <= and ~ / !~ for case-insensitive contains.
+// Values are bare words or single/double quoted strings.
+// attr.KEY refers to the custom attribute KEY of a table or record.
+// Expressions compile to a parameterized SQL condition against a Schema.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Node is an element of a parsed filter expression
+type Node interface {
+	compile(s Schema, b *builder) error
+}
+
+// And matches when both sides match
+type And struct{ Left, Right Node }
+
+// Or matches when either side matches
+type Or struct{ Left, Right Node }
+
+// Not inverts the wrapped expression
+type Not struct{ Expr Node }
+
+// Comparison tests one field against a literal value
+type Comparison struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Parse parses a filter expression into its syntax tree
+func Parse(input string) (Node, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators is ordered so two-character operators are matched first
+var operators = []string{"!=", ">=", "<=", "!~", "=", ">", "<", "~"}
+
+// lex splits the input into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	i := 0
+	for i < len(runes) {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != c {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{tokString, sb.String(), start})
+		default:
+			if op := matchOperator(runes[i:]); op != "" {
+				tokens = append(tokens, token{tokOp, op, i})
+				i += len(op)
+				continue
+			}
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) &&
+				runes[i] != '(' && runes[i] != ')' && matchOperator(runes[i:]) == "" {
+				i++
+			}
+			word := string(runes[start:i])
+			switch strings.ToUpper(word) {
+			case "AND":
+				tokens = append(tokens, token{tokAnd, word, start})
+			case "OR":
+				tokens = append(tokens, token{tokOr, word, start})
+			case "NOT":
+				tokens = append(tokens, token{tokNot, word, start})
+			default:
+				tokens = append(tokens, token{tokWord, word, start})
+			}
+		}
+	}
+	return append(tokens, token{tokEOF, "end of input", len(runes)}), nil
+}
+
+func matchOperator(runes []rune) string {
+	for _, op := range operators {
+		if strings.HasPrefix(string(runes[:min(len(runes), 2)]), op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// parser is a recursive descent parser over the token stream
+// Precedence from loosest to tightest: OR, AND, NOT
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &And{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) at position %d, got %q", closing.pos, closing.text)
+		}
+		return expr, nil
+	case tokWord:
+		op := p.next()
+		if op.kind != tokOp {
+			return nil, fmt.Errorf("expected operator after %q at position %d, got %q", tok.text, op.pos, op.text)
+		}
+		value := p.next()
+		if value.kind != tokWord && value.kind != tokString {
+			return nil, fmt.Errorf("expected value after %s%s at position %d, got %q", tok.text, op.text, value.pos, value.text)
+		}
+		return &Comparison{Field: tok.text, Op: op.text, Value: value.text}, nil
+	default:
+		return nil, fmt.Errorf("expected field or ( at position %d, got %q", tok.pos, tok.text)
+	}
+}
@@ -0,0 +1,94 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	f, err := Compile("type=fastq AND number>1000 AND label~control", Records)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	want := "((data_record.type = ? AND data_record.number > ?) AND COALESCE(data_record.label, '') LIKE ? ESCAPE '\\')"
+	if f.Where != want {
+		t.Errorf("Unexpected SQL:\n got: %s\nwant: %s", f.Where, want)
+	}
+	if !reflect.DeepEqual(f.Args, []any{"fastq", int64(1000), "%control%"}) {
+		t.Errorf("Unexpected args: %v", f.Args)
+	}
+}
+
+func TestCompilePrecedence(t *testing.T) {
+	f, err := Compile(`NOT tag=raw OR (type="a b" and number<=3)`, Records)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if !strings.HasPrefix(f.Where, "(NOT (EXISTS") || !strings.Contains(f.Where, " OR (") {
+		t.Errorf("Unexpected SQL: %s", f.Where)
+	}
+	if f.Args[1] != "a b" {
+		t.Errorf("Quoted value not preserved: %v", f.Args)
+	}
+}
+
+func TestCompileAttr(t *testing.T) {
+	f, err := Compile("attr.license=CC-BY AND attr.year>=2020", Tables)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	want := "(EXISTS (SELECT 1 FROM data_attribute WHERE data_attribute.name = data_table.name" +
+		" AND data_attribute.key = ? AND data_attribute.value = ?)" +
+		" AND EXISTS (SELECT 1 FROM data_attribute WHERE data_attribute.name = data_table.name" +
+		" AND data_attribute.key = ? AND CAST(data_attribute.value AS REAL) >= ?))"
+	if f.Where != want {
+		t.Errorf("Unexpected SQL:\n got: %s\nwant: %s", f.Where, want)
+	}
+	if !reflect.DeepEqual(f.Args, []any{"license", "CC-BY", "year", float64(2020)}) {
+		t.Errorf("Unexpected args: %v", f.Args)
+	}
+	if _, err := Records.OrderBy("attr.year"); err == nil {
+		t.Error("Expected error sorting by an attribute")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []string{
+		"",
+		"type",
+		"type=",
+		"unknown=1",
+		"number>many",
+		"modified>yesterday",
+		"(type=a",
+		"type=a AND",
+		"tag>x",
+		"attr.=x",
+		`label="open`,
+	}
+	for _, expr := range cases {
+		if _, err := Compile(expr, Records); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	order, err := Records.OrderBy("-modified, name")
+	if err != nil {
+		t.Fatalf("OrderBy failed: %v", err)
+	}
+	if order != "data_record.modify_at DESC, data_record.name ASC" {
+		t.Errorf("Unexpected ORDER BY: %s", order)
+	}
+	if _, err := Tables.OrderBy("label"); err == nil {
+		t.Error("Tables have no label field")
+	}
+}
+
+func TestLikePrefix(t *testing.T) {
+	if got := LikePrefix(`db:a_b\50%`); got != `db:a\_b\\50\%%` {
+		t.Errorf("Unexpected pattern: %s", got)
+	}
+}
@@ -0,0 +1,280 @@
+// Package schema implements the per-table validation rules of `srdm schema`
+//
+// A schema is a list of rules, one per line, that every record of the table
+// must satisfy. Blank lines and lines starting with # are ignored:
+//
+//	required units, label
+//	type in int|float|string
+//	missNumber <= number
+//	attr.license = CC-BY
+//
+// Fields are the record columns (name, type, label, number, ...) or custom
+// attributes, written as attr.KEY or as a bare KEY that is not a column.
+// Operators are = != > >= < <= and ~ (case-insensitive contains); the right
+// side is a field or a literal, and quoted values are always literals.
+// Values that are both numbers compare numerically, others as text.
+// Rules other than required only apply to fields that have a value.
+package schema
+
+import (
+	"fmt"
+	"srdm/internal/model"
+	"strconv"
+	"strings"
+)
+
+// Schema is a parsed list of rules
+type Schema struct {
+	Rules []Rule
+}
+
+// Rule is one line of a schema
+type Rule struct {
+	Text   string   // Rule as written
+	Op     string   // "required", "in" or a comparison operator
+	Fields []string // Checked fields; one unless Op is required
+	Values []string // Allowed values of in, or the right side of a comparison
+	Ref    bool     // The right side of a comparison is a field
+}
+
+// Violation is a rule a record does not satisfy
+type Violation struct {
+	Name    string `json:"name"`    // Full name of the record
+	Rule    string `json:"rule"`    // Rule as written
+	Message string `json:"message"` // What is wrong
+}
+
+// operators is ordered so two-character operators are matched first
+var operators = []string{"<=", ">=", "!=", "=", "<", ">", "~"}
+
+// recordFields reads the record columns a rule can refer to, keyed by lower-case name
+var recordFields = map[string]func(r *model.Record) string{
+	"name":         func(r *model.Record) string { return r.Name },
+	"database":     func(r *model.Record) string { return r.Database },
+	"table":        func(r *model.Record) string { return r.Table },
+	"type":         func(r *model.Record) string { return r.Type },
+	"source":       func(r *model.Record) string { return r.Source },
+	"label":        func(r *model.Record) string { return r.Label },
+	"description":  func(r *model.Record) string { return r.Description },
+	"number":       func(r *model.Record) string { return strconv.Itoa(r.Number) },
+	"missnumber":   func(r *model.Record) string { return strconv.Itoa(r.MissNumber) },
+	"uniquenumber": func(r *model.Record) string { return strconv.Itoa(r.UniqueNumber) },
+	"script_file":  func(r *model.Record) string { return r.ScriptFile },
+	"script_tag":   func(r *model.Record) string { return r.ScriptTag },
+	"desc_file":    func(r *model.Record) string { return r.DescFile },
+	"desc_tag":     func(r *model.Record) string { return r.DescTag },
+	"log_file":     func(r *model.Record) string { return r.LogFile },
+	"tags":         func(r *model.Record) string { return strings.Join(r.Tags, ",") },
+}
+
+// Parse parses schema text into its rules
+// Errors name the offending line
+func Parse(text string) (*Schema, error) {
+	s := &Schema{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema line %d %q: %w", i+1, line, err)
+		}
+		s.Rules = append(s.Rules, *rule)
+	}
+	return s, nil
+}
+
+// parseRule parses one non-empty rule line
+func parseRule(line string) (*Rule, error) {
+	rule := &Rule{Text: line}
+
+	if rest, ok := cutWord(line, "required"); ok {
+		for _, f := range strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			if err := checkField(f); err != nil {
+				return nil, err
+			}
+			rule.Fields = append(rule.Fields, f)
+		}
+		if len(rule.Fields) == 0 {
+			return nil, fmt.Errorf("required needs at least one field")
+		}
+		rule.Op = "required"
+		return rule, nil
+	}
+
+	field, rest, _ := strings.Cut(line, " ")
+	if values, ok := cutWord(strings.TrimSpace(rest), "in"); ok {
+		if err := checkField(field); err != nil {
+			return nil, err
+		}
+		for _, v := range strings.Split(values, "|") {
+			if v = unquote(strings.TrimSpace(v)); v != "" {
+				rule.Values = append(rule.Values, v)
+			}
+		}
+		if len(rule.Values) == 0 {
+			return nil, fmt.Errorf("in needs at least one value")
+		}
+		rule.Op, rule.Fields = "in", []string{field}
+		return rule, nil
+	}
+
+	for i := range line {
+		for _, op := range operators {
+			if !strings.HasPrefix(line[i:], op) {
+				continue
+			}
+			field := strings.TrimSpace(line[:i])
+			value := strings.TrimSpace(line[i+len(op):])
+			if err := checkField(field); err != nil {
+				return nil, err
+			}
+			if value == "" {
+				return nil, fmt.Errorf("missing value after %s", op)
+			}
+			rule.Op, rule.Fields = op, []string{field}
+			if quoted := unquote(value); quoted != value {
+				rule.Values = []string{quoted}
+			} else {
+				rule.Values, rule.Ref = []string{value}, isField(value)
+			}
+			return rule, nil
+		}
+	}
+	return nil, fmt.Errorf("expected 'required FIELD', 'FIELD in A|B' or 'FIELD OP VALUE'")
+}
+
+// Check returns the rules the record violates, in schema order
+func (s *Schema) Check(r *model.Record) []Violation {
+	var out []Violation
+	for _, rule := range s.Rules {
+		if msg := rule.check(r); msg != "" {
+			out = append(out, Violation{Name: r.FullName(), Rule: rule.Text, Message: msg})
+		}
+	}
+	return out
+}
+
+// check returns why the record violates the rule, or "" if it does not
+func (rule Rule) check(r *model.Record) string {
+	if rule.Op == "required" {
+		var missing []string
+		for _, f := range rule.Fields {
+			if value(r, f) == "" {
+				missing = append(missing, f)
+			}
+		}
+		if len(missing) > 0 {
+			return "missing " + strings.Join(missing, ", ")
+		}
+		return ""
+	}
+
+	field := rule.Fields[0]
+	got := value(r, field)
+	if got == "" {
+		return ""
+	}
+	if rule.Op == "in" {
+		for _, v := range rule.Values {
+			if got == v {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s %q is not one of %s", field, got, strings.Join(rule.Values, ", "))
+	}
+
+	want, desc := rule.Values[0], strconv.Quote(rule.Values[0])
+	if rule.Ref {
+		want = value(r, rule.Values[0])
+		if want == "" {
+			return ""
+		}
+		desc = fmt.Sprintf("%s (%s)", rule.Values[0], want)
+	}
+	if compare(got, rule.Op, want) {
+		return ""
+	}
+	return fmt.Sprintf("%s %q must be %s %s", field, got, rule.Op, desc)
+}
+
+// compare applies op, numerically when both sides are numbers
+func compare(a, op, b string) bool {
+	if op == "~" {
+		return strings.Contains(strings.ToLower(a), strings.ToLower(b))
+	}
+	c := strings.Compare(a, b)
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			c = -1
+		case x > y:
+			c = 1
+		default:
+			c = 0
+		}
+	}
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// value returns the text of a record column or attribute
+func value(r *model.Record, field string) string {
+	if key, ok := strings.CutPrefix(field, "attr."); ok {
+		return r.Attributes[key]
+	}
+	if get, ok := recordFields[strings.ToLower(field)]; ok {
+		return get(r)
+	}
+	return r.Attributes[field]
+}
+
+// isField reports whether a comparison's right side names a column or attribute
+// Bare words that are not columns are literals there
+func isField(s string) bool {
+	if key, ok := strings.CutPrefix(s, "attr."); ok {
+		return key != ""
+	}
+	_, ok := recordFields[strings.ToLower(s)]
+	return ok
+}
+
+// checkField rejects names that cannot be a column or attribute key
+func checkField(f string) error {
+	if f == "" || f == "attr." || strings.ContainsAny(f, " \t\"'=<>!~|") {
+		return fmt.Errorf("invalid field %q", f)
+	}
+	return nil
+}
+
+// cutWord returns the rest of s if it starts with word followed by a space
+func cutWord(s, word string) (string, bool) {
+	if len(s) <= len(word) || !strings.EqualFold(s[:len(word)], word) || (s[len(word)] != ' ' && s[len(word)] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(s[len(word):]), true
+}
+
+// unquote strips matching single or double quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package schema
+
+import (
+	"srdm/internal/model"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	s, err := Parse(`
+# every column needs a unit
+required units, label
+type in int|float|string
+missNumber <= number
+attr.license = "CC-BY"
+`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(s.Rules) != 4 || !s.Rules[2].Ref || s.Rules[3].Ref {
+		t.Fatalf("Unexpected rules: %+v", s.Rules)
+	}
+
+	ok := &model.Record{Database: "db", Table: "t", Name: "ok", Type: "int", Label: "x",
+		Number: 10, MissNumber: 2, Attributes: map[string]string{"units": "kg", "license": "CC-BY"}}
+	if v := s.Check(ok); len(v) != 0 {
+		t.Errorf("Expected no violations, got %+v", v)
+	}
+
+	// An unset license is not checked; only required demands a value
+	bad := &model.Record{Database: "db", Table: "t", Name: "bad", Type: "double", Number: 3, MissNumber: 5}
+	v := s.Check(bad)
+	if len(v) != 3 {
+		t.Fatalf("Expected 3 violations, got %+v", v)
+	}
+	if v[0].Message != "missing units, label" || v[0].Name != "db:t:bad" {
+		t.Errorf("Unexpected required violation: %+v", v[0])
+	}
+	if v[2].Message != `missNumber "5" must be <= number (3)` {
+		t.Errorf("Unexpected comparison violation: %q", v[2].Message)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		"required",
+		"type in |",
+		"number >",
+		"just words",
+		"a b = c",
+	}
+	for _, text := range cases {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Expected error for %q", text)
+		}
+	}
+}
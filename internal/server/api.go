@@ -0,0 +1,71 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
+)
+
+// openAPIDoc describes every route of the API
+//
+//go:embed openapi.json
+var openAPIDoc []byte
+
+// highlight marks the matched words of search snippets with brackets
+var highlight = strings.NewReplacer(store.HighlightStart, "[", store.HighlightEnd, "]")
+
+func (s *Server) openAPI(r *http.Request) (int, any, error) {
+	return http.StatusOK, json.RawMessage(openAPIDoc), nil
+}
+
+func (s *Server) stats(r *http.Request) (int, any, error) {
+	st, err := s.repo.GetStatistics()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, st, nil
+}
+
+// search runs a ranked full-text search for q
+// The hits may be narrowed to one kind and to names starting with prefix
+func (s *Server) search(r *http.Request) (int, any, error) {
+	params := r.URL.Query()
+	text := strings.TrimSpace(params.Get("q"))
+	if text == "" {
+		return 0, nil, badRequest("q is required")
+	}
+	kind := params.Get("kind")
+	if kind != "" && kind != "table" && kind != "record" {
+		return 0, nil, badRequest("kind must be table or record, got %q", kind)
+	}
+	prefix := params.Get("prefix")
+	limit, err := intParam(r, "limit")
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Filters apply after ranking, so the limit can only be passed on without them
+	searchLimit := limit
+	if kind != "" || prefix != "" {
+		searchLimit = 0
+	}
+	hits, err := s.repo.SearchText(text, searchLimit)
+	if err != nil {
+		return 0, nil, err
+	}
+	results := []model.TextHit{}
+	for _, h := range hits {
+		if (kind != "" && h.Kind != kind) || !strings.HasPrefix(h.Name, prefix) {
+			continue
+		}
+		h.Snippet = highlight.Replace(h.Snippet)
+		results = append(results, h)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+	return http.StatusOK, results, nil
+}
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"srdm/internal/model"
+	"srdm/internal/query"
+	"srdm/internal/store"
+	"strconv"
+	"strings"
+)
+
+// readOnlyFields cannot be changed by PATCH; names change with 'srdm rename'
+var readOnlyFields = []string{"database", "table", "name", "records", "create_at", "modify_at"}
+
+func (s *Server) listTables(r *http.Request) (int, any, error) {
+	q, err := listQuery(r, query.Tables)
+	if err != nil {
+		return 0, nil, err
+	}
+	tables, err := s.repo.FilterTables(q)
+	if err != nil {
+		return 0, nil, err
+	}
+	if tables == nil {
+		tables = []model.Table{}
+	}
+	return http.StatusOK, tables, nil
+}
+
+func (s *Server) listRecords(r *http.Request) (int, any, error) {
+	q, err := listQuery(r, query.Records)
+	if err != nil {
+		return 0, nil, err
+	}
+	records, err := s.repo.FilterRecords(q)
+	if err != nil {
+		return 0, nil, err
+	}
+	if records == nil {
+		records = []model.Record{}
+	}
+	return http.StatusOK, records, nil
+}
+
+func (s *Server) getTable(r *http.Request) (int, any, error) {
+	name := r.PathValue("name")
+	if err := requireParts(name, 2); err != nil {
+		return 0, nil, err
+	}
+	t, err := s.repo.GetTable(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, t, nil
+}
+
+func (s *Server) getRecord(r *http.Request) (int, any, error) {
+	name := r.PathValue("name")
+	if err := requireParts(name, 3); err != nil {
+		return 0, nil, err
+	}
+	rec, err := s.repo.GetRecord(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, rec, nil
+}
+
+// createTable inserts a table, with the records it may hold
+// The name may be given in full, as db:table, instead of database and name
+func (s *Server) createTable(r *http.Request) (int, any, error) {
+	var t model.Table
+	if err := decode(r, &t); err != nil {
+		return 0, nil, err
+	}
+	if t.Database == "" {
+		t.Database, t.Name, _ = strings.Cut(t.Name, ":")
+	}
+	if err := requireParts(t.FullName(), 2); err != nil {
+		return 0, nil, err
+	}
+	if t.Engine == "" {
+		t.Engine = "SQLite3"
+	}
+	for i := range t.Records {
+		rec := &t.Records[i]
+		if rec.Database == "" && rec.Table == "" {
+			rec.Database, rec.Table = t.Database, t.Name
+		}
+		if rec.Database != t.Database || rec.Table != t.Name {
+			return 0, nil, badRequest("record %s does not belong to %s", rec.FullName(), t.FullName())
+		}
+	}
+
+	if err := s.repo.InsertTable(&t); err != nil {
+		return 0, nil, err
+	}
+	created, err := s.repo.GetTable(t.FullName())
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, created, nil
+}
+
+// createRecord inserts a record into an existing table
+// The name may be given in full, as db:table:record
+func (s *Server) createRecord(r *http.Request) (int, any, error) {
+	var rec model.Record
+	if err := decode(r, &rec); err != nil {
+		return 0, nil, err
+	}
+	if rec.Database == "" && rec.Table == "" {
+		if parts := strings.Split(rec.Name, ":"); len(parts) == 3 {
+			rec.Database, rec.Table, rec.Name = parts[0], parts[1], parts[2]
+		}
+	}
+	if err := requireParts(rec.FullName(), 3); err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.repo.InsertRecord(&rec); err != nil {
+		return 0, nil, err
+	}
+	created, err := s.repo.GetRecord(rec.FullName())
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, created, nil
+}
+
+func (s *Server) patchTable(r *http.Request) (int, any, error) {
+	name := r.PathValue("name")
+	if err := requireParts(name, 2); err != nil {
+		return 0, nil, err
+	}
+	t, err := s.repo.GetTable(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := patch(r, t); err != nil {
+		return 0, nil, err
+	}
+	if err := s.repo.UpdateTable(t); err != nil {
+		return 0, nil, err
+	}
+	return s.getTable(r)
+}
+
+func (s *Server) patchRecord(r *http.Request) (int, any, error) {
+	name := r.PathValue("name")
+	if err := requireParts(name, 3); err != nil {
+		return 0, nil, err
+	}
+	rec, err := s.repo.GetRecord(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := patch(r, rec); err != nil {
+		return 0, nil, err
+	}
+	if err := s.repo.UpdateRecord(rec); err != nil {
+		return 0, nil, err
+	}
+	return s.getRecord(r)
+}
+
+// deleteItem moves a table (parts 2) or record (parts 3) to the trash
+// A table holding records is only deleted with ?force=true
+func (s *Server) deleteItem(parts int) handlerFunc {
+	return func(r *http.Request) (int, any, error) {
+		name := r.PathValue("name")
+		if err := requireParts(name, parts); err != nil {
+			return 0, nil, err
+		}
+		force := false
+		if v := r.URL.Query().Get("force"); v != "" {
+			var err error
+			if force, err = strconv.ParseBool(v); err != nil {
+				return 0, nil, badRequest("force must be true or false, got %q", v)
+			}
+		}
+		if err := s.repo.Delete(name, force); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	}
+}
+
+// patch merges the JSON object of the request body into item
+// Fields present in the body replace those of item; attributes are merged
+// key by key, and an empty or null value removes the attribute
+func patch[T any](r *http.Request, item *T) error {
+	var changes map[string]json.RawMessage
+	if err := decode(r, &changes); err != nil {
+		return err
+	}
+	for _, f := range readOnlyFields {
+		if _, ok := changes[f]; ok {
+			return badRequest("%s cannot be changed with PATCH", f)
+		}
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for k, v := range changes {
+		if k == "attributes" {
+			merged, err := mergeAttributes(fields[k], v)
+			if err != nil {
+				return err
+			}
+			v = merged
+		}
+		fields[k] = v
+	}
+
+	if data, err = json.Marshal(fields); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var changed T // Decoding into item would merge maps instead of replacing them
+	if err := dec.Decode(&changed); err != nil {
+		return badRequest("invalid change: %v", err)
+	}
+	*item = changed
+	return nil
+}
+
+// mergeAttributes applies attribute changes to the current attributes
+func mergeAttributes(current, changes json.RawMessage) (json.RawMessage, error) {
+	attrs := map[string]string{}
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &attrs); err != nil {
+			return nil, err
+		}
+		if attrs == nil {
+			attrs = map[string]string{}
+		}
+	}
+	var set map[string]*string
+	if err := json.Unmarshal(changes, &set); err != nil {
+		return nil, badRequest("attributes must be an object of strings: %v", err)
+	}
+	for k, v := range set {
+		if v == nil || *v == "" {
+			delete(attrs, k)
+		} else {
+			attrs[k] = *v
+		}
+	}
+	return json.Marshal(attrs)
+}
+
+// listQuery builds a query from the where, prefix, sort, limit and offset parameters
+func listQuery(r *http.Request, schema query.Schema) (store.Query, error) {
+	params := r.URL.Query()
+	var q store.Query
+	if where := params.Get("where"); where != "" {
+		f, err := query.Compile(where, schema)
+		if err != nil {
+			return q, badRequest("%v", err)
+		}
+		q.Where, q.Args = "("+f.Where+")", f.Args
+	}
+	if prefix := params.Get("prefix"); prefix != "" {
+		cond := schema.Table + ".name LIKE ? ESCAPE '\\'"
+		if q.Where != "" {
+			cond = q.Where + " AND " + cond
+		}
+		q.Where = cond
+		q.Args = append(q.Args, query.LikePrefix(prefix))
+	}
+
+	order, err := schema.OrderBy(params.Get("sort"))
+	if err != nil {
+		return q, badRequest("%v", err)
+	}
+	q.OrderBy = order
+	if q.Limit, err = intParam(r, "limit"); err != nil {
+		return q, err
+	}
+	if q.Offset, err = intParam(r, "offset"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// intParam parses a non-negative integer query parameter, 0 if absent
+func intParam(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, badRequest("%s must be a non-negative integer, got %q", name, v)
+	}
+	return n, nil
+}
+
+// requireParts checks that name is a table (2 parts) or record (3 parts) name
+func requireParts(name string, want int) error {
+	parts, err := store.SplitName(name)
+	if err != nil {
+		return err
+	}
+	if len(parts) != want {
+		kind := "db:table"
+		if want == 3 {
+			kind = "db:table:record"
+		}
+		return fmt.Errorf("%w %q: expected %s", store.ErrInvalidName, name, kind)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Package server exposes a repository as a JSON REST API for `srdm serve`
+//
+// Tables and records are addressed by full name under /api/tables/{name} and
+// /api/records/{name}; the routes are described by the OpenAPI document at
+// /api/openapi.json. Writes hold an exclusive lock, so requests never race on
+// the single SQLite file; reads share the lock and run concurrently.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"srdm/internal/store"
+	"sync"
+	"time"
+)
+
+// maxBody caps the size of request bodies
+const maxBody = 10 << 20
+
+// Server serves the API over a repository
+type Server struct {
+	repo store.Repository
+	mu   sync.RWMutex // Held exclusively by writes
+	mux  *http.ServeMux
+	log  *slog.Logger
+}
+
+// New returns a server over repo
+// Requests are logged to log; nil disables logging
+func New(repo store.Repository, log *slog.Logger) *Server {
+	if log == nil {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	s := &Server{repo: repo, mux: http.NewServeMux(), log: log}
+	s.routes()
+	return s
+}
+
+// routes registers every endpoint
+func (s *Server) routes() {
+	s.read("GET /api/openapi.json", s.openAPI)
+	s.read("GET /api/stats", s.stats)
+	s.read("GET /api/search", s.search)
+
+	s.read("GET /api/tables", s.listTables)
+	s.write("POST /api/tables", s.createTable)
+	s.read("GET /api/tables/{name}", s.getTable)
+	s.write("PATCH /api/tables/{name}", s.patchTable)
+	s.write("DELETE /api/tables/{name}", s.deleteItem(2))
+
+	s.read("GET /api/records", s.listRecords)
+	s.write("POST /api/records", s.createRecord)
+	s.read("GET /api/records/{name}", s.getRecord)
+	s.write("PATCH /api/records/{name}", s.patchRecord)
+	s.write("DELETE /api/records/{name}", s.deleteItem(3))
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	s.log.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+}
+
+// handlerFunc handles a request and returns the status and JSON body of the response
+// A nil body sends no content
+type handlerFunc func(r *http.Request) (int, any, error)
+
+// read registers a handler that may run alongside other reads
+func (s *Server) read(pattern string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		respond(w, r, h)
+	})
+}
+
+// write registers a handler that runs alone
+func (s *Server) write(pattern string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		respond(w, r, h)
+	})
+}
+
+// respond runs h and writes its result or error
+func respond(w http.ResponseWriter, r *http.Request, h handlerFunc) {
+	status, body, err := h(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, status, body)
+}
+
+// errBadRequest marks errors in the request itself
+var errBadRequest = errors.New("bad request")
+
+// badRequest returns an error answered with 400 Bad Request
+func badRequest(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errBadRequest, fmt.Sprintf(format, args...))
+}
+
+// apiError is the body of error responses
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// statusOf returns the HTTP status of an error
+func statusOf(err error) int {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, errBadRequest), errors.Is(err, store.ErrInvalidName):
+		return http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrAlreadyExists), errors.Is(err, store.ErrHasChildren):
+		return http.StatusConflict
+	case errors.Is(err, store.ErrSchemaViolation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, store.ErrTextSearchUnavailable):
+		return http.StatusNotImplemented
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers with the status of err and its message
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), apiError{Error: err.Error()})
+}
+
+// writeJSON writes body as JSON, or no content if body is nil
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(body)
+}
+
+// decode reads a JSON request body into v, rejecting unknown fields
+func decode(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return err
+		}
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// statusRecorder remembers the status code written by a handler, for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
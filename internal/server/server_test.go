@@ -0,0 +1,282 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"srdm/internal/model"
+	"srdm/internal/store"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setupServer serves a fresh repository
+func setupServer(t *testing.T) (*httptest.Server, *store.DB) {
+	t.Helper()
+	db, err := store.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	ts := httptest.NewServer(New(db, nil))
+	t.Cleanup(func() {
+		ts.Close()
+		db.Close()
+	})
+	return ts, db
+}
+
+// call sends a request and decodes the JSON response into out, if not nil
+func call(t *testing.T, ts *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestTableLifecycle(t *testing.T) {
+	ts, _ := setupServer(t)
+
+	var created model.Table
+	body := `{"name": "proj:survey", "keys": "id", "description": "Household survey",
+		"tags": ["raw"], "attributes": {"license": "CC-BY-4.0", "owner": "lab"},
+		"records": [{"name": "age", "type": "int"}]}`
+	if code := call(t, ts, "POST", "/api/tables", body, &created); code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want 201", code)
+	}
+	if created.Database != "proj" || created.Name != "survey" || created.Engine != "SQLite3" {
+		t.Errorf("created = %s (%s), want proj:survey (SQLite3)", created.FullName(), created.Engine)
+	}
+	if len(created.Records) != 1 || created.Records[0].FullName() != "proj:survey:age" {
+		t.Errorf("created records = %+v", created.Records)
+	}
+	if code := call(t, ts, "POST", "/api/tables", body, nil); code != http.StatusConflict {
+		t.Errorf("duplicate POST status = %d, want 409", code)
+	}
+
+	var patched model.Table
+	code := call(t, ts, "PATCH", "/api/tables/proj:survey",
+		`{"description": "Survey 2024", "tags": ["clean"], "attributes": {"owner": null, "unit": "household"}}`, &patched)
+	if code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, want 200", code)
+	}
+	if patched.Description != "Survey 2024" || patched.Keys != "id" {
+		t.Errorf("patched = %+v", patched)
+	}
+	if len(patched.Tags) != 1 || patched.Tags[0] != "clean" {
+		t.Errorf("patched tags = %v, want [clean]", patched.Tags)
+	}
+	want := map[string]string{"license": "CC-BY-4.0", "unit": "household"}
+	if fmt.Sprint(patched.Attributes) != fmt.Sprint(want) {
+		t.Errorf("patched attributes = %v, want %v", patched.Attributes, want)
+	}
+	if code := call(t, ts, "PATCH", "/api/tables/proj:survey", `{"name": "other"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("PATCH name status = %d, want 400", code)
+	}
+	if code := call(t, ts, "PATCH", "/api/tables/proj:survey", `{"color": "red"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("PATCH unknown field status = %d, want 400", code)
+	}
+
+	var got model.Table
+	if code := call(t, ts, "GET", "/api/tables/proj:survey", "", &got); code != http.StatusOK {
+		t.Fatalf("GET status = %d, want 200", code)
+	}
+	if got.Description != "Survey 2024" {
+		t.Errorf("GET description = %q", got.Description)
+	}
+
+	if code := call(t, ts, "DELETE", "/api/tables/proj:survey", "", nil); code != http.StatusConflict {
+		t.Errorf("DELETE with records status = %d, want 409", code)
+	}
+	if code := call(t, ts, "DELETE", "/api/tables/proj:survey?force=true", "", nil); code != http.StatusNoContent {
+		t.Errorf("forced DELETE status = %d, want 204", code)
+	}
+	if code := call(t, ts, "GET", "/api/tables/proj:survey", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET deleted status = %d, want 404", code)
+	}
+}
+
+func TestRecordLifecycle(t *testing.T) {
+	ts, _ := setupServer(t)
+
+	if code := call(t, ts, "POST", "/api/records", `{"name": "proj:survey:age"}`, nil); code != http.StatusNotFound {
+		t.Errorf("POST without table status = %d, want 404", code)
+	}
+	call(t, ts, "POST", "/api/tables", `{"database": "proj", "name": "survey"}`, nil)
+
+	var rec model.Record
+	if code := call(t, ts, "POST", "/api/records", `{"name": "proj:survey:age", "type": "int"}`, &rec); code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want 201", code)
+	}
+	if rec.Database != "proj" || rec.Table != "survey" || rec.Name != "age" {
+		t.Errorf("created = %+v", rec)
+	}
+	if code := call(t, ts, "PATCH", "/api/records/proj:survey:age", `{"label": "Age in years"}`, &rec); code != http.StatusOK {
+		t.Fatalf("PATCH status = %d, want 200", code)
+	}
+	if rec.Label != "Age in years" || rec.Type != "int" {
+		t.Errorf("patched = %+v", rec)
+	}
+	if code := call(t, ts, "GET", "/api/records/proj:survey", "", nil); code != http.StatusBadRequest {
+		t.Errorf("GET table name as record status = %d, want 400", code)
+	}
+	if code := call(t, ts, "DELETE", "/api/records/proj:survey:age", "", nil); code != http.StatusNoContent {
+		t.Errorf("DELETE status = %d, want 204", code)
+	}
+	if code := call(t, ts, "GET", "/api/records/proj:survey:age", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET deleted status = %d, want 404", code)
+	}
+}
+
+func TestList(t *testing.T) {
+	ts, db := setupServer(t)
+	for _, name := range []string{"proj:a", "proj:b", "other:c"} {
+		parts := strings.SplitN(name, ":", 2)
+		if err := db.InsertTable(&model.Table{Database: parts[0], Name: parts[1], Tags: []string{"t-" + parts[1]}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, name := range []string{"x", "y", "z"} {
+		if err := db.InsertRecord(&model.Record{Database: "proj", Table: "a", Name: name, Number: i * 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/api/tables", []string{"other:c", "proj:a", "proj:b"}},
+		{"/api/tables?prefix=proj:", []string{"proj:a", "proj:b"}},
+		{"/api/tables?where=tag%3Dt-b", []string{"proj:b"}},
+		{"/api/tables?sort=-name&limit=2", []string{"proj:b", "proj:a"}},
+		{"/api/records?where=number%3E0&sort=-number", []string{"proj:a:z", "proj:a:y"}},
+		{"/api/records?offset=1&limit=1", []string{"proj:a:y"}},
+		{"/api/records?prefix=none", []string{}},
+	}
+	for _, tt := range tests {
+		var items []struct {
+			Database string `json:"database"`
+			Table    string `json:"table"`
+			Name     string `json:"name"`
+		}
+		if code := call(t, ts, "GET", tt.path, "", &items); code != http.StatusOK {
+			t.Errorf("GET %s status = %d, want 200", tt.path, code)
+			continue
+		}
+		if items == nil {
+			t.Errorf("GET %s returned null, want a list", tt.path)
+		}
+		got := []string{}
+		for _, it := range items {
+			got = append(got, strings.Join(append([]string{it.Database}, strings.Fields(it.Table+" "+it.Name)...), ":"))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("GET %s = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"/api/tables?where=nosuch%3D1", "/api/records?sort=tag", "/api/records?limit=-1"} {
+		var e apiError
+		if code := call(t, ts, "GET", path, "", &e); code != http.StatusBadRequest || e.Error == "" {
+			t.Errorf("GET %s = %d %q, want 400 with a message", path, code, e.Error)
+		}
+	}
+}
+
+func TestStatsSearchAndOpenAPI(t *testing.T) {
+	ts, db := setupServer(t)
+	if err := db.InsertTable(&model.Table{Database: "proj", Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var stats model.Stats
+	if code := call(t, ts, "GET", "/api/stats", "", &stats); code != http.StatusOK || stats.TableCount != 1 {
+		t.Errorf("stats = %d %+v, want 200 with one table", code, stats)
+	}
+
+	if code := call(t, ts, "GET", "/api/search", "", nil); code != http.StatusBadRequest {
+		t.Errorf("search without q status = %d, want 400", code)
+	}
+	if code := call(t, ts, "GET", "/api/search?q=proj", "", nil); code != http.StatusOK && code != http.StatusNotImplemented {
+		t.Errorf("search status = %d, want 200, or 501 without fts5", code)
+	}
+
+	var doc struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if code := call(t, ts, "GET", "/api/openapi.json", "", &doc); code != http.StatusOK {
+		t.Fatalf("openapi status = %d, want 200", code)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi version = %q", doc.OpenAPI)
+	}
+	for _, p := range []string{"/api/tables", "/api/tables/{name}", "/api/records", "/api/records/{name}", "/api/search", "/api/stats"} {
+		if _, ok := doc.Paths[p]; !ok {
+			t.Errorf("openapi document lacks %s", p)
+		}
+	}
+
+	if code := call(t, ts, "PUT", "/api/tables", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT status = %d, want 405", code)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	ts, db := setupServer(t)
+	call(t, ts, "POST", "/api/tables", `{"name": "proj:big"}`, nil)
+
+	var wg sync.WaitGroup
+	codes := make([]int, 20)
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"name": "proj:big:v%d"}`, i)
+			req, _ := http.NewRequest("POST", ts.URL+"/api/records", strings.NewReader(body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+			codes[i] = resp.StatusCode
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(ts.URL + "/api/tables/proj:big")
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusCreated {
+			t.Errorf("POST v%d status = %d, want 201", i, code)
+		}
+	}
+	table, err := db.GetTable("proj:big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Records) != len(codes) {
+		t.Errorf("table has %d records, want %d", len(table.Records), len(codes))
+	}
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseAttribute splits a key=value argument into its trimmed key and value
+// The key must be non-empty; the value may be empty
+func ParseAttribute(arg string) (string, string, error) {
+	key, value, ok := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid attribute %q (expected key=value)", arg)
+	}
+	return key, strings.TrimSpace(value), nil
+}
+
+// setAttributes replaces the attributes of a table or record
+// Attributes with an empty value are not stored
+func (db *DB) setAttributes(name string, attrs map[string]string) error {
+	if _, err := db.Exec("DELETE FROM data_attribute WHERE name = ?", name); err != nil {
+		return fmt.Errorf("failed to clear attributes: %w", err)
+	}
+	for key, value := range attrs {
+		if key == "" || value == "" {
+			continue
+		}
+		if _, err := db.Exec(
+			"INSERT INTO data_attribute (name, key, value) VALUES (?, ?, ?)", name, key, value,
+		); err != nil {
+			return fmt.Errorf("failed to set attribute %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// loadAttributes returns the attributes of a single name, or nil if it has none
+func (db *DB) loadAttributes(name string) (map[string]string, error) {
+	rows, err := db.Query("SELECT key, value FROM data_attribute WHERE name = ?", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attributes of %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	var attrs map[string]string
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if attrs == nil {
+			attrs = map[string]string{}
+		}
+		attrs[key] = value
+	}
+	return attrs, rows.Err()
+}
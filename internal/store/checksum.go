@@ -0,0 +1,72 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"srdm/internal/model"
+)
+
+// SaveChecksum stores the registered state of a file referenced by a table or record
+// An existing checksum of the same name and field is replaced
+func (db *DB) SaveChecksum(c *model.Checksum) error {
+	if _, err := db.Exec(`
+	INSERT INTO data_checksum (name, field, path, size, mtime, sha256, checked_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (name, field) DO UPDATE SET
+		path = excluded.path, size = excluded.size, mtime = excluded.mtime,
+		sha256 = excluded.sha256, checked_at = excluded.checked_at
+	`, c.Name, c.Field, c.Path, c.Size, c.ModTime, c.SHA256, c.CheckedAt); err != nil {
+		return fmt.Errorf("failed to save checksum: %w", err)
+	}
+	return nil
+}
+
+// RemoveChecksum forgets the checksum of one field of a table or record
+func (db *DB) RemoveChecksum(name, field string) error {
+	if _, err := db.Exec("DELETE FROM data_checksum WHERE name = ? AND field = ?", name, field); err != nil {
+		return fmt.Errorf("failed to remove checksum: %w", err)
+	}
+	return nil
+}
+
+// Checksum returns the checksum of one field of a table or record, matched by exact name
+// It returns ErrNotFound if none is stored
+func (db *DB) Checksum(name, field string) (*model.Checksum, error) {
+	var c model.Checksum
+	err := db.QueryRow(`
+	SELECT name, field, path, size, mtime, sha256, checked_at
+	FROM data_checksum WHERE name = ? AND field = ?
+	`, name, field).Scan(&c.Name, &c.Field, &c.Path, &c.Size, &c.ModTime, &c.SHA256, &c.CheckedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: checksum %s %s", ErrNotFound, name, field)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checksum: %w", err)
+	}
+	return &c, nil
+}
+
+// Checksums returns the checksums of items whose name matches pattern, a name or
+// glob such as 'survey:*', including the records of a matching table or database,
+// ordered by name and field
+func (db *DB) Checksums(pattern string) ([]model.Checksum, error) {
+	rows, err := db.Query(`
+	SELECT name, field, path, size, mtime, sha256, checked_at
+	FROM data_checksum WHERE name GLOB ?1 OR name GLOB ?1 || ':*'
+	ORDER BY name, field
+	`, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checksums: %w", err)
+	}
+	defer rows.Close()
+
+	var sums []model.Checksum
+	for rows.Next() {
+		var c model.Checksum
+		if err := rows.Scan(&c.Name, &c.Field, &c.Path, &c.Size, &c.ModTime, &c.SHA256, &c.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan checksum: %w", err)
+		}
+		sums = append(sums, c)
+	}
+	return sums, rows.Err()
+}
@@ -16,6 +16,69 @@ import (
 type DB struct {
 	*sql.DB
 	Path string
+
+	// textSearch reports whether the FTS5 search index is available
+	textSearch bool
+
+	// tx is set on the copy of DB that runs inside a transaction
+	tx *sql.Tx
+}
+
+// Exec runs a statement in the current transaction, if any
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	if db.tx != nil {
+		return db.tx.Exec(query, args...)
+	}
+	return db.DB.Exec(query, args...)
+}
+
+// Query runs a query in the current transaction, if any
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	if db.tx != nil {
+		return db.tx.Query(query, args...)
+	}
+	return db.DB.Query(query, args...)
+}
+
+// QueryRow runs a single-row query in the current transaction, if any
+func (db *DB) QueryRow(query string, args ...any) *sql.Row {
+	if db.tx != nil {
+		return db.tx.QueryRow(query, args...)
+	}
+	return db.DB.QueryRow(query, args...)
+}
+
+// WithTx runs fn in a single transaction
+// fn must use the Repository it is given: the transaction commits when fn returns nil
+// and rolls back when it returns an error, so a failure never leaves partial state
+func (db *DB) WithTx(fn func(Repository) error) error {
+	return db.withTx(func(tx *DB) error {
+		return fn(tx)
+	})
+}
+
+// withTx runs fn against a copy of db bound to a new transaction
+// The transaction commits when fn succeeds and rolls back otherwise
+// Nested calls join the enclosing transaction
+func (db *DB) withTx(fn func(tx *DB) error) error {
+	if db.tx != nil {
+		return fn(db)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	scoped := *db
+	scoped.tx = tx
+	if err := fn(&scoped); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 // GetPath returns the file system path to the database
@@ -96,9 +159,33 @@ func (db *DB) GetStatistics() (*model.Stats, error) {
 
 // NewDB creates and initializes a new DB instance
 // Creates the database directory if it doesn't exist
-// Initializes the database schema with required tables and indexes
+// Brings the database schema up to date by applying pending migrations
 // dbPath: The file system path where the SQLite database will be created/opened
 func NewDB(dbPath string) (*DB, error) {
+	sdb, err := OpenDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Upgrade schema tables and indexes to the latest version
+	if _, err := sdb.Migrate(); err != nil {
+		sdb.Close()
+		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	// Keep the full-text index in step with what this build supports
+	if err := sdb.ensureSearchIndex(); err != nil {
+		sdb.Close()
+		return nil, fmt.Errorf("failed to initialize search index: %w", err)
+	}
+
+	return sdb, nil
+}
+
+// OpenDB opens the SQLite database without touching its schema
+// Used by commands that need to inspect the schema version before upgrading
+// dbPath: The file system path where the SQLite database will be created/opened
+func OpenDB(dbPath string) (*DB, error) {
 	// Ensure parent directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -106,7 +193,9 @@ func NewDB(dbPath string) (*DB, error) {
 	}
 
 	// Open SQLite database connection
-	db, err := sql.Open("sqlite3", dbPath)
+	// Transactions take the write lock immediately so concurrent upgrades serialize
+	// Foreign keys are enforced so records cannot outlive their table
+	db, err := sql.Open("sqlite3", dbPath+"?_txlock=immediate&_busy_timeout=5000&_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -117,70 +206,5 @@ func NewDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	// Initialize DB wrapper
-	sdb := &DB{DB: db, Path: dbPath}
-
-	// Create schema tables and indexes
-	if err := sdb.initSchema(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return sdb, nil
-}
-
-// initSchema creates the required database tables and indexes
-// Creates data_table for storing table metadata
-// Creates data_record for storing record/column metadata
-// Both tables include automatic timestamp tracking
-func (db *DB) initSchema() error {
-	// Define schema for data_table (stores table-level metadata)
-	tableSchema := `
-	CREATE TABLE IF NOT EXISTS data_table (
-		name            VARCHAR PRIMARY KEY,
-		keys            VARCHAR NOT NULL,
-		path            VARCHAR NOT NULL,
-		engine          VARCHAR NOT NULL DEFAULT 'SQLite3',
-		source          VARCHAR,
-		description     VARCHAR,
-		script_file     VARCHAR,
-		script_tag      VARCHAR,
-		desc_file       VARCHAR,
-		desc_tag        VARCHAR,
-		log_file        VARCHAR,
-		create_at       TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
-		modify_at       TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
-	);
-	CREATE INDEX IF NOT EXISTS data_table_name ON data_table (name);
-	`
-	if _, err := db.Exec(tableSchema); err != nil {
-		return fmt.Errorf("failed to create data_table: %w", err)
-	}
-
-	// Define schema for data_record (stores record/column-level metadata)
-	recordSchema := `
-	CREATE TABLE IF NOT EXISTS data_record (
-		name         VARCHAR PRIMARY KEY,
-		type         VARCHAR NOT NULL,
-		source       VARCHAR NOT NULL DEFAULT 'unknown',
-		label        VARCHAR NOT NULL,
-		description  VARCHAR,
-		number       INTEGER,
-		missNumber   INTEGER,
-		uniqueNumber INTEGER,
-		script_file  VARCHAR,
-		script_tag   VARCHAR,
-		desc_file    VARCHAR,
-		desc_tag     VARCHAR,
-		log_file     VARCHAR,
-		create_at    TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
-		modify_at    TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
-	);
-	CREATE INDEX IF NOT EXISTS data_record_name ON data_record (name);
-	`
-	if _, err := db.Exec(recordSchema); err != nil {
-		return fmt.Errorf("failed to create data_record: %w", err)
-	}
-
-	return nil
+	return &DB{DB: db, Path: dbPath}, nil
 }
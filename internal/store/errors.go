@@ -0,0 +1,74 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Errors returned by Repository methods
+// They are wrapped with the offending name; test for them with errors.Is
+var (
+	// ErrNotFound means the table, record or other item does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists means an item with the same name exists
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrHasChildren means a table still holds records
+	ErrHasChildren = errors.New("has children")
+	// ErrInvalidName means a name is not db:table or db:table:record
+	ErrInvalidName = errors.New("invalid name")
+	// ErrSchemaViolation means a record breaks the validation schema of its table
+	ErrSchemaViolation = errors.New("schema violation")
+)
+
+// notFound reports that name does not exist
+func notFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// constraintError maps SQLite constraint violations on name to the matching sentinel
+// Other errors are returned unchanged
+func constraintError(err error, name string) error {
+	var se sqlite3.Error
+	if !errors.As(err, &se) || se.Code != sqlite3.ErrConstraint {
+		return err
+	}
+	switch se.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, name)
+	}
+	return err
+}
+
+// SplitName splits a full name into its db, table and, for records, record parts
+// It fails with ErrInvalidName unless there are two or three non-empty parts
+func SplitName(name string) ([]string, error) {
+	parts := strings.Split(name, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("%w %q: use 'db:table' for a table or 'db:table:record' for a record", ErrInvalidName, name)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("%w %q: empty name part", ErrInvalidName, name)
+		}
+	}
+	return parts, nil
+}
+
+// validateName checks that name has the number of parts of a table (2) or record (3)
+func validateName(name string, want int) error {
+	parts, err := SplitName(name)
+	if err != nil {
+		return err
+	}
+	if len(parts) != want {
+		kind := "table"
+		if want == 3 {
+			kind = "record"
+		}
+		return fmt.Errorf("%w %q: not a %s name", ErrInvalidName, name, kind)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"fmt"
+	"srdm/internal/model"
+)
+
+// Query selects rows with a parameterized condition, ordering and paging
+// Where and OrderBy are SQL fragments built by the query package
+type Query struct {
+	Where   string
+	Args    []any
+	OrderBy string
+	Limit   int
+	Offset  int
+}
+
+// sql appends the query clauses to a SELECT statement
+func (q Query) sql(base, defaultOrder string) (string, []any) {
+	stmt := base
+	if q.Where != "" {
+		stmt += " WHERE " + q.Where
+	}
+	order := q.OrderBy
+	if order == "" {
+		order = defaultOrder
+	}
+	stmt += " ORDER BY " + order
+
+	args := append([]any{}, q.Args...)
+	if q.Limit > 0 || q.Offset > 0 {
+		limit := q.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		stmt += " LIMIT ? OFFSET ?"
+		args = append(args, limit, q.Offset)
+	}
+	return stmt, args
+}
+
+// FilterRecords returns the records matching a query
+func (db *DB) FilterRecords(q Query) ([]model.Record, error) {
+	stmt, args := q.sql(`SELECT `+recordColumns+` FROM data_record`, "data_record.name")
+	records, err := db.queryRecords(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter records: %w", err)
+	}
+	return records, nil
+}
+
+// FilterTables returns the tables matching a query, with their records
+func (db *DB) FilterTables(q Query) ([]model.Table, error) {
+	stmt, args := q.sql(`SELECT name FROM data_table`, "data_table.name")
+	names, err := db.queryStrings(stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter tables: %w", err)
+	}
+
+	tables := make([]model.Table, 0, len(names))
+	for _, name := range names {
+		t, err := db.GetTable(name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, *t)
+	}
+	return tables, nil
+}
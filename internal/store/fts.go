@@ -0,0 +1,161 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"srdm/internal/model"
+	"strings"
+)
+
+// ErrTextSearchUnavailable is returned when SQLite was built without FTS5
+var ErrTextSearchUnavailable = errors.New("full-text search requires srdm built with the sqlite_fts5 tag")
+
+// Markers wrapped around matched terms in TextHit.Snippet
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// searchIndexTriggers lists the triggers keeping search_index in sync
+// with data_table and data_record
+var searchIndexTriggers = []string{
+	"data_table_fts_insert", "data_table_fts_update", "data_table_fts_delete",
+	"data_record_fts_insert", "data_record_fts_update", "data_record_fts_delete",
+}
+
+// ensureSearchIndex prepares the FTS5 index over tables and records
+//
+// The index is derived data, so it lives outside the versioned migrations:
+// a binary built with FTS5 (re)creates and rebuilds it when its triggers are missing,
+// while a binary without FTS5 drops the triggers so writes keep working.
+// The next FTS5-enabled open then rebuilds the stale index.
+func (db *DB) ensureSearchIndex() error {
+	var available int
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available); err != nil {
+		return fmt.Errorf("failed to detect FTS5 support: %w", err)
+	}
+	db.textSearch = available == 1
+
+	var triggers int
+	if err := db.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('" +
+			strings.Join(searchIndexTriggers, "', '") + "')",
+	).Scan(&triggers); err != nil {
+		return fmt.Errorf("failed to inspect search index: %w", err)
+	}
+
+	if !db.textSearch {
+		for _, name := range searchIndexTriggers {
+			if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return fmt.Errorf("failed to drop trigger %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+	if triggers == len(searchIndexTriggers) {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin search index rebuild: %w", err)
+	}
+	defer tx.Rollback()
+
+	indexSchema := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+		name, kind UNINDEXED, description, source, label
+	);
+	DELETE FROM search_index;
+	INSERT INTO search_index (name, kind, description, source, label)
+		SELECT name, 'table', COALESCE(description, ''), COALESCE(source, ''), '' FROM data_table;
+	INSERT INTO search_index (name, kind, description, source, label)
+		SELECT name, 'record', COALESCE(description, ''), COALESCE(source, ''), COALESCE(label, '') FROM data_record;
+
+	CREATE TRIGGER IF NOT EXISTS data_table_fts_insert AFTER INSERT ON data_table BEGIN
+		INSERT INTO search_index (name, kind, description, source, label)
+		VALUES (new.name, 'table', COALESCE(new.description, ''), COALESCE(new.source, ''), '');
+	END;
+	CREATE TRIGGER IF NOT EXISTS data_table_fts_update AFTER UPDATE ON data_table BEGIN
+		DELETE FROM search_index WHERE name = old.name;
+		INSERT INTO search_index (name, kind, description, source, label)
+		VALUES (new.name, 'table', COALESCE(new.description, ''), COALESCE(new.source, ''), '');
+	END;
+	CREATE TRIGGER IF NOT EXISTS data_table_fts_delete AFTER DELETE ON data_table BEGIN
+		DELETE FROM search_index WHERE name = old.name;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS data_record_fts_insert AFTER INSERT ON data_record BEGIN
+		INSERT INTO search_index (name, kind, description, source, label)
+		VALUES (new.name, 'record', COALESCE(new.description, ''), COALESCE(new.source, ''), COALESCE(new.label, ''));
+	END;
+	CREATE TRIGGER IF NOT EXISTS data_record_fts_update AFTER UPDATE ON data_record BEGIN
+		DELETE FROM search_index WHERE name = old.name;
+		INSERT INTO search_index (name, kind, description, source, label)
+		VALUES (new.name, 'record', COALESCE(new.description, ''), COALESCE(new.source, ''), COALESCE(new.label, ''));
+	END;
+	CREATE TRIGGER IF NOT EXISTS data_record_fts_delete AFTER DELETE ON data_record BEGIN
+		DELETE FROM search_index WHERE name = old.name;
+	END;
+	`
+	if _, err := tx.Exec(indexSchema); err != nil {
+		return fmt.Errorf("failed to build search index: %w", err)
+	}
+	return tx.Commit()
+}
+
+// SearchText runs a ranked full-text search over names, descriptions, sources and labels
+// Each whitespace-separated word must match; results are ordered by BM25 relevance
+func (db *DB) SearchText(query string, limit int) ([]model.TextHit, error) {
+	if !db.textSearch {
+		return nil, ErrTextSearchUnavailable
+	}
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, fmt.Errorf("empty search text")
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := db.Query(`
+	SELECT name, kind, snippet(search_index, -1, ?, ?, '…', 12), bm25(search_index)
+	FROM search_index
+	WHERE search_index MATCH ?
+	ORDER BY bm25(search_index)
+	LIMIT ?
+	`, HighlightStart, HighlightEnd, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search text: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []model.TextHit
+	for rows.Next() {
+		var h model.TextHit
+		if err := rows.Scan(&h.Name, &h.Kind, &h.Snippet, &h.Rank); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// ftsQuery quotes each word so user input cannot trip the FTS5 query syntax
+// A trailing * on a word is kept as a prefix match
+func ftsQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.TrimSuffix(word, "*")
+		if word == "" {
+			continue
+		}
+		term := `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"srdm/internal/model"
+	"strings"
+	"time"
+)
+
+// History returns every recorded change of a table or record, oldest first
+func (db *DB) History(name string) ([]model.HistoryEntry, error) {
+	rows, err := db.Query(`
+	SELECT id, name, version, action, before_json, after_json, user, changed_at
+	FROM data_history WHERE name = ? ORDER BY version
+	`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.HistoryEntry
+	for rows.Next() {
+		e, err := scanHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+// Revert restores a table or record to the state recorded after the given version
+// The restore itself is appended to the history as a new version
+// The item's own fields, tags and attributes are restored; a table's records keep their state
+func (db *DB) Revert(name string, version int) error {
+	return db.withTx(func(tx *DB) error {
+		return tx.revert(name, version)
+	})
+}
+
+// revert restores one version within the caller's transaction
+func (db *DB) revert(name string, version int) error {
+	row := db.QueryRow(`
+	SELECT id, name, version, action, before_json, after_json, user, changed_at
+	FROM data_history WHERE name = ? AND version = ?
+	`, name, version)
+	e, err := scanHistory(row)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: version %d of %s", ErrNotFound, version, name)
+	}
+	if err != nil {
+		return err
+	}
+	if e.After == nil {
+		return fmt.Errorf("version %d of %s is a deletion; revert to an earlier version", version, name)
+	}
+
+	current, err := db.snapshot(name)
+	if err != nil {
+		return err
+	}
+	exists := current != nil
+
+	// Versions recorded before a rename carry the old name; the item keeps its current one
+	parts := strings.Split(name, ":")
+	return db.trackChange(name, model.ActionRevert, func(tx *DB) error {
+		if len(parts) == 3 {
+			var r model.Record
+			if err := json.Unmarshal(e.After, &r); err != nil {
+				return fmt.Errorf("failed to decode version %d: %w", version, err)
+			}
+			r.Database, r.Table, r.Name = parts[0], parts[1], parts[2]
+			if exists {
+				return tx.updateRecord(&r)
+			}
+			return tx.insertRecord(&r)
+		}
+
+		var t model.Table
+		if err := json.Unmarshal(e.After, &t); err != nil {
+			return fmt.Errorf("failed to decode version %d: %w", version, err)
+		}
+		t.Database, t.Name = parts[0], parts[1]
+		if exists {
+			return tx.updateTable(&t)
+		}
+		return tx.insertTable(&t)
+	})
+}
+
+// trackChange runs mutate and appends the before/after snapshots of name to the history
+// Everything runs in one transaction, passed to mutate as tx
+// Nothing is recorded when mutate fails or leaves the item unchanged
+func (db *DB) trackChange(name, action string, mutate func(tx *DB) error) error {
+	return db.withTx(func(tx *DB) error {
+		before, err := tx.snapshot(name)
+		if err != nil {
+			return err
+		}
+		if err := mutate(tx); err != nil {
+			return err
+		}
+		after, err := tx.snapshot(name)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(before, after) {
+			return nil
+		}
+		return tx.recordHistory(name, action, before, after)
+	})
+}
+
+// recordHistory appends one entry with the next version number of name
+func (db *DB) recordHistory(name, action string, before, after []byte) error {
+	_, err := db.Exec(`
+	INSERT INTO data_history (name, version, action, before_json, after_json, user, changed_at)
+	VALUES (?, (SELECT COALESCE(MAX(version), 0) + 1 FROM data_history WHERE name = ?), ?, ?, ?, ?, ?)
+	`, name, name, action, nullJSON(before), nullJSON(after), currentUser(), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+	return nil
+}
+
+// snapshot returns the JSON state of a table or record, or nil if it does not exist
+// Table snapshots omit the records, which have their own history
+func (db *DB) snapshot(name string) ([]byte, error) {
+	var item any
+	if strings.Count(name, ":") == 2 {
+		r, err := db.GetRecord(name)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		item = r
+	} else {
+		t, err := db.GetTable(name)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		t.Records = nil
+		item = t
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot of %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanHistory reads one data_history row
+func scanHistory(row rowScanner) (*model.HistoryEntry, error) {
+	var e model.HistoryEntry
+	var before, after sql.NullString
+	err := row.Scan(&e.ID, &e.Name, &e.Version, &e.Action, &before, &after, &e.User, &e.ChangedAt)
+	if err != nil {
+		return nil, err
+	}
+	if before.Valid {
+		e.Before = json.RawMessage(before.String)
+	}
+	if after.Valid {
+		e.After = json.RawMessage(after.String)
+	}
+	return &e, nil
+}
+
+// nullJSON maps a missing snapshot to SQL NULL
+func nullJSON(data []byte) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+// currentUser returns the OS user recorded in the history
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	for _, env := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"srdm/internal/model"
+	"time"
+)
+
+// ErrImportFailed is returned when at least one row of an import failed
+// and the whole import was rolled back
+var ErrImportFailed = errors.New("import failed, no changes were made")
+
+// errDryRun rolls back the transaction of a dry-run import
+var errDryRun = errors.New("dry run")
+
+// ConflictPolicy decides what happens when an imported item already exists
+type ConflictPolicy string
+
+const (
+	ConflictSkip   ConflictPolicy = "skip"   // Keep the existing item
+	ConflictUpdate ConflictPolicy = "update" // Overwrite the existing item
+	ConflictFail   ConflictPolicy = "fail"   // Report the row as failed
+)
+
+// ParseConflictPolicy validates an --on-conflict value
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case ConflictSkip, ConflictUpdate, ConflictFail:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid conflict policy %q (use skip, update or fail)", s)
+}
+
+// Import outcomes reported per row
+const (
+	ImportInserted = "inserted"
+	ImportUpdated  = "updated"
+	ImportSkipped  = "skipped"
+	ImportFailed   = "failed"
+)
+
+// ImportItem is one table or record read from an import file
+// Exactly one of Table and Record is set
+type ImportItem struct {
+	Row    int // Position in the source file, for error reports
+	Table  *model.Table
+	Record *model.Record
+}
+
+// Name returns the full name of the imported item
+func (it ImportItem) Name() string {
+	if it.Table != nil {
+		return it.Table.FullName()
+	}
+	if it.Record != nil {
+		return it.Record.FullName()
+	}
+	return ""
+}
+
+// ImportOptions controls how Import applies the items
+type ImportOptions struct {
+	OnConflict ConflictPolicy
+	DryRun     bool // Apply everything, then roll back
+}
+
+// ImportResult reports the outcome of one imported item
+type ImportResult struct {
+	Row    int
+	Name   string
+	Action string
+	Err    error
+}
+
+// Import applies the items in a single transaction
+// Every item is attempted so all row errors are reported together;
+// if any row fails nothing is committed and ErrImportFailed is returned
+func (db *DB) Import(items []ImportItem, opts ImportOptions) ([]ImportResult, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ConflictFail
+	}
+
+	// Tables go first so records may precede their table in the input
+	order := make([]int, 0, len(items))
+	for i, it := range items {
+		if it.Table != nil {
+			order = append(order, i)
+		}
+	}
+	for i, it := range items {
+		if it.Table == nil {
+			order = append(order, i)
+		}
+	}
+
+	results := make([]ImportResult, len(items))
+	err := db.withTx(func(tx *DB) error {
+		failed := false
+		for _, i := range order {
+			it := items[i]
+			action, err := tx.importItem(it, opts.OnConflict)
+			results[i] = ImportResult{Row: it.Row, Name: it.Name(), Action: action, Err: err}
+			if err != nil {
+				results[i].Action = ImportFailed
+				failed = true
+			}
+		}
+		if failed {
+			return ErrImportFailed
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return results, err
+}
+
+// importItem inserts or, depending on the policy, updates one item
+func (db *DB) importItem(it ImportItem, policy ConflictPolicy) (string, error) {
+	name := it.Name()
+	if err := validateImportName(it); err != nil {
+		return "", err
+	}
+
+	current, err := db.snapshot(name)
+	if err != nil {
+		return "", err
+	}
+	if current != nil {
+		switch policy {
+		case ConflictSkip:
+			return ImportSkipped, nil
+		case ConflictUpdate:
+			if it.Table != nil {
+				return ImportUpdated, db.UpdateTable(it.Table)
+			}
+			return ImportUpdated, db.UpdateRecord(it.Record)
+		default:
+			return "", fmt.Errorf("%w: %s", ErrAlreadyExists, name)
+		}
+	}
+
+	now := time.Now()
+	if it.Table != nil {
+		stampTimes(&it.Table.CreateAt, &it.Table.ModifyAt, now)
+		return ImportInserted, db.InsertTable(it.Table)
+	}
+	stampTimes(&it.Record.CreateAt, &it.Record.ModifyAt, now)
+	return ImportInserted, db.InsertRecord(it.Record)
+}
+
+// validateImportName rejects items with an empty or malformed name
+func validateImportName(it ImportItem) error {
+	switch {
+	case it.Table != nil:
+		return validateName(it.Table.FullName(), 2)
+	case it.Record != nil:
+		return validateName(it.Record.FullName(), 3)
+	}
+	return fmt.Errorf("empty item")
+}
+
+// stampTimes fills missing creation and modification times
+func stampTimes(create, modify *time.Time, now time.Time) {
+	if create.IsZero() {
+		*create = now
+	}
+	if modify.IsZero() {
+		*modify = now
+	}
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"fmt"
+	"srdm/internal/model"
+)
+
+// AddLineage records that e.Name was derived from, or produced by, e.Upstream
+// The derived item must exist; for derived_from the upstream item must exist too
+func (db *DB) AddLineage(e *model.LineageEdge) error {
+	return db.withTx(func(tx *DB) error {
+		if err := tx.checkLineage(e); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO data_lineage (name, upstream, relation) VALUES (?, ?, ?)",
+			e.Name, e.Upstream, e.Relation,
+		); err != nil {
+			return fmt.Errorf("failed to add lineage: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveLineage deletes a single lineage edge
+func (db *DB) RemoveLineage(e *model.LineageEdge) error {
+	res, err := db.Exec(
+		"DELETE FROM data_lineage WHERE name = ? AND upstream = ? AND relation = ?",
+		e.Name, e.Upstream, e.Relation,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove lineage: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: lineage %s %s %s", ErrNotFound, e.Name, e.Relation, e.Upstream)
+	}
+	return nil
+}
+
+// Lineage returns every edge reachable from name
+// downstream=false walks towards the raw inputs name was derived from
+// downstream=true walks towards everything derived from name
+func (db *DB) Lineage(name string, downstream bool) ([]model.LineageEdge, error) {
+	// UNION (not UNION ALL) drops already visited nodes, so cycles terminate
+	query := `
+	WITH RECURSIVE walk(node) AS (
+		SELECT ?
+		UNION
+		SELECT l.upstream FROM data_lineage l JOIN walk ON l.name = walk.node
+	)
+	SELECT l.name, l.upstream, l.relation, l.create_at
+	FROM data_lineage l JOIN walk ON l.name = walk.node
+	ORDER BY l.name, l.relation, l.upstream
+	`
+	if downstream {
+		query = `
+		WITH RECURSIVE walk(node) AS (
+			SELECT ?
+			UNION
+			SELECT l.name FROM data_lineage l JOIN walk ON l.upstream = walk.node
+		)
+		SELECT l.name, l.upstream, l.relation, l.create_at
+		FROM data_lineage l JOIN walk ON l.upstream = walk.node
+		ORDER BY l.name, l.relation, l.upstream
+		`
+	}
+
+	rows, err := db.Query(query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lineage: %w", err)
+	}
+	defer rows.Close()
+
+	var edges []model.LineageEdge
+	for rows.Next() {
+		var e model.LineageEdge
+		if err := rows.Scan(&e.Name, &e.Upstream, &e.Relation, &e.CreateAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lineage: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// checkLineage validates an edge before it is stored
+func (db *DB) checkLineage(e *model.LineageEdge) error {
+	switch e.Relation {
+	case model.RelationDerivedFrom, model.RelationProducedByScript:
+	default:
+		return fmt.Errorf("invalid lineage relation: %s", e.Relation)
+	}
+	if e.Name == e.Upstream {
+		return fmt.Errorf("%s cannot be derived from itself", e.Name)
+	}
+	if err := db.requireItem(e.Name); err != nil {
+		return err
+	}
+	if e.Relation == model.RelationDerivedFrom {
+		return db.requireItem(e.Upstream)
+	}
+	return nil
+}
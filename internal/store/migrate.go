@@ -0,0 +1,359 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrSchemaTooNew is returned when the repository was written by a newer srdm
+var ErrSchemaTooNew = errors.New("repository schema is newer than this version of srdm supports")
+
+// Migration describes one ordered step of the schema upgrade path
+// Version numbers start at 1 and must be strictly increasing
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+// migrations lists every schema upgrade step in the order it is applied
+// Never edit or reorder released steps; append a new one instead
+var migrations = []Migration{
+	{1, "create data_table and data_record", migrateCreateBaseTables},
+	{2, "create data_tag for many-to-many tagging", migrateCreateTags},
+	{3, "create data_lineage for the lineage graph", migrateCreateLineage},
+	{4, "create data_history for the audit trail", migrateCreateHistory},
+	{5, "create data_checksum for file integrity checks", migrateCreateChecksums},
+	{6, "link data_record to its parent data_table", migrateAddRecordParent},
+	{7, "create data_attribute for custom metadata", migrateCreateAttributes},
+	{8, "create data_schema for per-table validation rules", migrateCreateSchemas},
+	{9, "create data_trash for soft-deleted items", migrateCreateTrash},
+}
+
+// LatestSchemaVersion returns the schema version this binary upgrades to
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// SchemaVersion returns the schema version currently recorded in the repository
+// Repositories created before versioning was introduced report version 0
+func (db *DB) SchemaVersion() (int, error) {
+	return schemaVersion(db.DB)
+}
+
+// PendingMigrations returns the migrations not yet applied to the repository
+func (db *DB) PendingMigrations() ([]Migration, error) {
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current > LatestSchemaVersion() {
+		return nil, fmt.Errorf("%w (repository: %d, supported: %d)", ErrSchemaTooNew, current, LatestSchemaVersion())
+	}
+	return pendingAfter(current), nil
+}
+
+// Migrate applies all pending migrations inside a single transaction
+// Either every pending step is applied or none of them is
+// Returns the migrations that were applied
+func (db *DB) Migrate() ([]Migration, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin migration: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		description VARCHAR NOT NULL,
+		applied_at  TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
+	);
+	`); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	// Read the version under the write lock so concurrent openers cannot
+	// apply the same step twice
+	current, err := schemaVersion(tx)
+	if err != nil {
+		return nil, err
+	}
+	if current > LatestSchemaVersion() {
+		return nil, fmt.Errorf("%w (repository: %d, supported: %d)", ErrSchemaTooNew, current, LatestSchemaVersion())
+	}
+
+	pending := pendingAfter(current)
+	for _, m := range pending {
+		if err := m.Up(tx); err != nil {
+			return nil, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO schema_migrations (version, description) VALUES (?, ?)",
+			m.Version, m.Description,
+		); err != nil {
+			return nil, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return pending, nil
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// schemaVersion reads the highest applied migration version
+func schemaVersion(q queryRower) (int, error) {
+	var exists int
+	err := q.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	var version sql.NullInt64
+	if err := q.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// pendingAfter returns the migrations with a version above current
+func pendingAfter(current int) []Migration {
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// migrateCreateBaseTables creates data_table for storing table metadata
+// and data_record for storing record/column metadata
+// Both tables include automatic timestamp tracking
+// Uses IF NOT EXISTS so repositories created before versioning adopt it unchanged
+func migrateCreateBaseTables(tx *sql.Tx) error {
+	// Define schema for data_table (stores table-level metadata)
+	tableSchema := `
+	CREATE TABLE IF NOT EXISTS data_table (
+		name            VARCHAR PRIMARY KEY,
+		keys            VARCHAR NOT NULL,
+		path            VARCHAR NOT NULL,
+		engine          VARCHAR NOT NULL DEFAULT 'SQLite3',
+		source          VARCHAR,
+		description     VARCHAR,
+		script_file     VARCHAR,
+		script_tag      VARCHAR,
+		desc_file       VARCHAR,
+		desc_tag        VARCHAR,
+		log_file        VARCHAR,
+		create_at       TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
+		modify_at       TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
+	);
+	CREATE INDEX IF NOT EXISTS data_table_name ON data_table (name);
+	`
+	if _, err := tx.Exec(tableSchema); err != nil {
+		return fmt.Errorf("failed to create data_table: %w", err)
+	}
+
+	// Define schema for data_record (stores record/column-level metadata)
+	recordSchema := `
+	CREATE TABLE IF NOT EXISTS data_record (
+		name         VARCHAR PRIMARY KEY,
+		type         VARCHAR NOT NULL,
+		source       VARCHAR NOT NULL DEFAULT 'unknown',
+		label        VARCHAR NOT NULL,
+		description  VARCHAR,
+		number       INTEGER,
+		missNumber   INTEGER,
+		uniqueNumber INTEGER,
+		script_file  VARCHAR,
+		script_tag   VARCHAR,
+		desc_file    VARCHAR,
+		desc_tag     VARCHAR,
+		log_file     VARCHAR,
+		create_at    TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
+		modify_at    TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
+	);
+	CREATE INDEX IF NOT EXISTS data_record_name ON data_record (name);
+	`
+	if _, err := tx.Exec(recordSchema); err != nil {
+		return fmt.Errorf("failed to create data_record: %w", err)
+	}
+
+	return nil
+}
+
+// migrateCreateTags creates data_tag linking table and record names to tags
+// name holds the full name of either a table (db:table) or a record (db:table:record)
+func migrateCreateTags(tx *sql.Tx) error {
+	tagSchema := `
+	CREATE TABLE IF NOT EXISTS data_tag (
+		name      VARCHAR NOT NULL,
+		tag       VARCHAR NOT NULL,
+		create_at TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
+		PRIMARY KEY (name, tag)
+	);
+	CREATE INDEX IF NOT EXISTS data_tag_tag ON data_tag (tag);
+	`
+	if _, err := tx.Exec(tagSchema); err != nil {
+		return fmt.Errorf("failed to create data_tag: %w", err)
+	}
+	return nil
+}
+
+// migrateCreateLineage creates data_lineage holding the edges of the lineage graph
+// Each row states that name was derived from, or produced by, upstream
+func migrateCreateLineage(tx *sql.Tx) error {
+	lineageSchema := `
+	CREATE TABLE IF NOT EXISTS data_lineage (
+		name      VARCHAR NOT NULL,
+		upstream  VARCHAR NOT NULL,
+		relation  VARCHAR NOT NULL CHECK (relation IN ('derived_from', 'produced_by_script')),
+		create_at TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
+		PRIMARY KEY (name, upstream, relation)
+	);
+	CREATE INDEX IF NOT EXISTS data_lineage_upstream ON data_lineage (upstream);
+	`
+	if _, err := tx.Exec(lineageSchema); err != nil {
+		return fmt.Errorf("failed to create data_lineage: %w", err)
+	}
+	return nil
+}
+
+// migrateCreateHistory creates the append-only data_history audit table
+// version counts the changes of each name separately, starting at 1
+func migrateCreateHistory(tx *sql.Tx) error {
+	historySchema := `
+	CREATE TABLE IF NOT EXISTS data_history (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		name        VARCHAR NOT NULL,
+		version     INTEGER NOT NULL,
+		action      VARCHAR NOT NULL,
+		before_json TEXT,
+		after_json  TEXT,
+		user        VARCHAR NOT NULL,
+		changed_at  TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
+		UNIQUE (name, version)
+	);
+	`
+	if _, err := tx.Exec(historySchema); err != nil {
+		return fmt.Errorf("failed to create data_history: %w", err)
+	}
+	return nil
+}
+
+// migrateCreateChecksums creates data_checksum holding the registered state
+// of the files referenced by tables and records, one row per name and field
+func migrateCreateChecksums(tx *sql.Tx) error {
+	checksumSchema := `
+	CREATE TABLE IF NOT EXISTS data_checksum (
+		name       VARCHAR NOT NULL,
+		field      VARCHAR NOT NULL,
+		path       VARCHAR NOT NULL,
+		size       INTEGER NOT NULL,
+		mtime      TIMESTAMP NOT NULL,
+		sha256     VARCHAR NOT NULL,
+		checked_at TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME')),
+		PRIMARY KEY (name, field)
+	);
+	`
+	if _, err := tx.Exec(checksumSchema); err != nil {
+		return fmt.Errorf("failed to create data_checksum: %w", err)
+	}
+	return nil
+}
+
+// migrateAddRecordParent adds data_record.table_name referencing the parent table
+// Records of a deleted or renamed table follow it through the cascade actions.
+// Existing records are linked to their table; orphans keep a NULL parent
+// until srdm doctor creates their table or removes them
+func migrateAddRecordParent(tx *sql.Tx) error {
+	parentSchema := `
+	ALTER TABLE data_record ADD COLUMN table_name VARCHAR
+		REFERENCES data_table (name) ON DELETE CASCADE ON UPDATE CASCADE;
+	CREATE INDEX IF NOT EXISTS data_record_table_name ON data_record (table_name);
+	`
+	if _, err := tx.Exec(parentSchema); err != nil {
+		return fmt.Errorf("failed to add data_record.table_name: %w", err)
+	}
+
+	// The parent of db:table:record is the table whose name plus ':' prefixes it
+	if _, err := tx.Exec(`
+	UPDATE data_record SET table_name = (
+		SELECT t.name FROM data_table t
+		WHERE substr(data_record.name, 1, length(t.name) + 1) = t.name || ':'
+		AND instr(substr(data_record.name, length(t.name) + 2), ':') = 0
+	)
+	`); err != nil {
+		return fmt.Errorf("failed to link records to their tables: %w", err)
+	}
+	return nil
+}
+
+// migrateCreateAttributes creates data_attribute holding custom key/value metadata
+// name holds the full name of either a table (db:table) or a record (db:table:record)
+func migrateCreateAttributes(tx *sql.Tx) error {
+	attributeSchema := `
+	CREATE TABLE IF NOT EXISTS data_attribute (
+		name  VARCHAR NOT NULL,
+		key   VARCHAR NOT NULL,
+		value VARCHAR NOT NULL,
+		PRIMARY KEY (name, key)
+	);
+	CREATE INDEX IF NOT EXISTS data_attribute_key ON data_attribute (key, value);
+	`
+	if _, err := tx.Exec(attributeSchema); err != nil {
+		return fmt.Errorf("failed to create data_attribute: %w", err)
+	}
+	return nil
+}
+
+// migrateCreateSchemas creates data_schema holding the validation rules of a table's records
+// The rules follow their table when it is renamed or deleted
+func migrateCreateSchemas(tx *sql.Tx) error {
+	schemaSchema := `
+	CREATE TABLE IF NOT EXISTS data_schema (
+		table_name VARCHAR PRIMARY KEY
+			REFERENCES data_table (name) ON DELETE CASCADE ON UPDATE CASCADE,
+		rules      TEXT NOT NULL,
+		modify_at  TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
+	);
+	`
+	if _, err := tx.Exec(schemaSchema); err != nil {
+		return fmt.Errorf("failed to create data_schema: %w", err)
+	}
+	return nil
+}
+
+// migrateCreateTrash creates data_trash holding deleted tables and records until they are
+// restored or purged. content is the JSON of the item with everything needed to restore it
+func migrateCreateTrash(tx *sql.Tx) error {
+	trashSchema := `
+	CREATE TABLE IF NOT EXISTS data_trash (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		name       VARCHAR NOT NULL,
+		kind       VARCHAR NOT NULL CHECK (kind IN ('table', 'record')),
+		records    INTEGER NOT NULL DEFAULT 0,
+		content    TEXT NOT NULL,
+		user       VARCHAR NOT NULL,
+		deleted_at TIMESTAMP NOT NULL DEFAULT (DATETIME('NOW', 'LOCALTIME'))
+	);
+	CREATE INDEX IF NOT EXISTS data_trash_name ON data_trash (name);
+	`
+	if _, err := tx.Exec(trashSchema); err != nil {
+		return fmt.Errorf("failed to create data_trash: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateFreshDB(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion failed: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("Expected version %d, got %d", LatestSchemaVersion(), version)
+	}
+
+	pending, err := db.PendingMigrations()
+	if err != nil {
+		t.Fatalf("PendingMigrations failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("Expected no pending migrations, got %d", len(pending))
+	}
+}
+
+func TestMigrateLegacyDB(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+
+	// Simulate a repository created before schema versioning
+	legacy, err := OpenDB(dbPath)
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	tx, err := legacy.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if err := migrateCreateBaseTables(tx); err != nil {
+		t.Fatalf("Creating legacy schema failed: %v", err)
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO data_table (
+			name, keys, path, source, description,
+			script_file, script_tag, desc_file, desc_tag, log_file
+		) VALUES ('db1:tbl1', 'id', '/tmp/x', '', '', '', '', '', '', '')`,
+	); err != nil {
+		t.Fatalf("Insert legacy row failed: %v", err)
+	}
+	// One record of the table and one orphan under a mistyped table name
+	if _, err := tx.Exec(
+		`INSERT INTO data_record (name, type, label, description, number, missNumber, uniqueNumber,
+			script_file, script_tag, desc_file, desc_tag, log_file)
+		VALUES ('db1:tbl1:rec1', '', '', '', 0, 0, 0, '', '', '', '', ''),
+			('db1:tlb1:rec2', '', '', '', 0, 0, 0, '', '', '', '', '')`,
+	); err != nil {
+		t.Fatalf("Insert legacy records failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if v, _ := legacy.SchemaVersion(); v != 0 {
+		t.Errorf("Expected legacy version 0, got %d", v)
+	}
+	legacy.Close()
+
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("NewDB failed to upgrade legacy repository: %v", err)
+	}
+	defer db.Close()
+
+	if v, _ := db.SchemaVersion(); v != LatestSchemaVersion() {
+		t.Errorf("Expected version %d after upgrade, got %d", LatestSchemaVersion(), v)
+	}
+	tbl, err := db.GetTable("db1:tbl1")
+	if err != nil || tbl == nil {
+		t.Fatalf("Legacy data lost after upgrade: %v", err)
+	}
+
+	// Records are linked to their table; the orphan is left for doctor
+	orphans, err := db.Orphans()
+	if err != nil {
+		t.Fatalf("Orphans failed: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].FullName() != "db1:tlb1:rec2" {
+		t.Errorf("Expected the mistyped record as the only orphan, got %+v", orphans)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "newer.db")
+	db, err := NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("NewDB failed: %v", err)
+	}
+	if _, err := db.Exec(
+		"INSERT INTO schema_migrations (version, description) VALUES (?, 'from the future')",
+		LatestSchemaVersion()+1,
+	); err != nil {
+		t.Fatalf("Insert future version failed: %v", err)
+	}
+	db.Close()
+
+	if _, err := NewDB(dbPath); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"fmt"
+	"srdm/internal/model"
+)
+
+// renamedColumns are the columns outside data_table and data_record holding full names
+var renamedColumns = []struct{ table, column string }{
+	{"data_tag", "name"},
+	{"data_attribute", "name"},
+	{"data_checksum", "name"},
+	{"data_lineage", "name"},
+	{"data_lineage", "upstream"},
+}
+
+// Rename gives a table or record a new name, which may be in another database or table
+// A table's records move with it. Tags, attributes, lineage, checksums and history follow the
+// new names, and every renamed item gets a rename entry in its history.
+// Fails with ErrNotFound if oldName or the new parent table does not exist,
+// ErrAlreadyExists if newName is taken and ErrInvalidName if the kinds differ
+func (db *DB) Rename(oldName, newName string) error {
+	return db.withTx(func(tx *DB) error {
+		return tx.rename(oldName, newName)
+	})
+}
+
+// rename renames within the caller's transaction
+func (db *DB) rename(oldName, newName string) error {
+	oldParts, err := SplitName(oldName)
+	if err != nil {
+		return err
+	}
+	newParts, err := SplitName(newName)
+	if err != nil {
+		return err
+	}
+	if len(oldParts) != len(newParts) {
+		return fmt.Errorf("%w: cannot rename %s to %s: a table can only become a table and a record a record",
+			ErrInvalidName, oldName, newName)
+	}
+	if oldName == newName {
+		return nil
+	}
+
+	before, err := db.snapshot(oldName)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return notFound(oldName)
+	}
+	taken, err := db.snapshot(newName)
+	if err != nil {
+		return err
+	}
+	if taken != nil {
+		return fmt.Errorf("%w: %s", ErrAlreadyExists, newName)
+	}
+
+	// moves pairs every affected old name with its new name, the item itself first
+	moves := [][2]string{{oldName, newName}}
+	befores := [][]byte{before}
+	if len(oldParts) == 2 {
+		records, err := db.queryStrings("SELECT name FROM data_record WHERE table_name = ? ORDER BY name", oldName)
+		if err != nil {
+			return fmt.Errorf("failed to list records of %s: %w", oldName, err)
+		}
+		for _, from := range records {
+			snap, err := db.snapshot(from)
+			if err != nil {
+				return err
+			}
+			moves = append(moves, [2]string{from, newName + from[len(oldName):]})
+			befores = append(befores, snap)
+		}
+		// table_name follows through ON UPDATE CASCADE; the record names are rewritten here
+		if _, err := db.Exec("UPDATE data_table SET name = ? WHERE name = ?", newName, oldName); err != nil {
+			return constraintError(fmt.Errorf("failed to rename table: %w", err), newName)
+		}
+		if _, err := db.Exec(
+			"UPDATE data_record SET name = ?1 || substr(name, length(?2) + 1) WHERE table_name = ?1",
+			newName, oldName,
+		); err != nil {
+			return fmt.Errorf("failed to rename records of %s: %w", oldName, err)
+		}
+	} else {
+		parent := newParts[0] + ":" + newParts[1]
+		if _, err := db.GetTable(parent); err != nil {
+			return fmt.Errorf("cannot move %s: %w", oldName, err)
+		}
+		// The record must satisfy the schema of its new table
+		r, err := db.GetRecord(oldName)
+		if err != nil {
+			return err
+		}
+		r.Database, r.Table, r.Name = newParts[0], newParts[1], newParts[2]
+		if err := db.checkSchema(r); err != nil {
+			return err
+		}
+		if _, err := db.Exec(
+			"UPDATE data_record SET name = ?, table_name = ? WHERE name = ?",
+			newName, parent, oldName,
+		); err != nil {
+			return constraintError(fmt.Errorf("failed to rename record: %w", err), newName)
+		}
+	}
+
+	for _, m := range moves {
+		if err := db.moveReferences(m[0], m[1]); err != nil {
+			return err
+		}
+	}
+
+	for i, m := range moves {
+		after, err := db.snapshot(m[1])
+		if err != nil {
+			return err
+		}
+		if err := db.recordHistory(m[1], model.ActionRename, befores[i], after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveReferences points the tags, attributes, checksums, lineage and history of oldName to newName
+// History versions continue after any entries left under newName by a deleted item
+func (db *DB) moveReferences(oldName, newName string) error {
+	for _, c := range renamedColumns {
+		stmt := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", c.table, c.column, c.column)
+		if _, err := db.Exec(stmt, newName, oldName); err != nil {
+			return fmt.Errorf("failed to rename %s in %s: %w", oldName, c.table, err)
+		}
+	}
+
+	var offset int
+	if err := db.QueryRow(
+		"SELECT COALESCE(MAX(version), 0) FROM data_history WHERE name = ?", newName,
+	).Scan(&offset); err != nil {
+		return fmt.Errorf("failed to read history of %s: %w", newName, err)
+	}
+	if _, err := db.Exec(
+		"UPDATE data_history SET name = ?, version = version + ? WHERE name = ?",
+		newName, offset, oldName,
+	); err != nil {
+		return fmt.Errorf("failed to move history of %s: %w", oldName, err)
+	}
+	return nil
+}
@@ -2,9 +2,12 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"srdm/internal/model"
+	"srdm/internal/schema"
 	"strings"
+	"time"
 )
 
 // Repository defines the data storage interface
@@ -17,14 +20,72 @@ type Repository interface {
 	UpdateRecord(r *model.Record) error
 	GetStatistics() (*model.Stats, error)
 	SearchRecords(pattern string) ([]model.Record, error)
+	SearchText(query string, limit int) ([]model.TextHit, error)
+	FilterRecords(q Query) ([]model.Record, error)
+	FilterTables(q Query) ([]model.Table, error)
 	Delete(name string, force bool) error
+	Rename(oldName, newName string) error
+	AddTags(name string, tags ...string) error
+	RemoveTags(name string, tags ...string) error
+	ListTags(name string) ([]string, error)
+	TaggedNames(tags ...string) ([]string, error)
+	AddLineage(e *model.LineageEdge) error
+	RemoveLineage(e *model.LineageEdge) error
+	Lineage(name string, downstream bool) ([]model.LineageEdge, error)
+	History(name string) ([]model.HistoryEntry, error)
+	Revert(name string, version int) error
+	SaveChecksum(c *model.Checksum) error
+	RemoveChecksum(name, field string) error
+	Checksum(name, field string) (*model.Checksum, error)
+	Checksums(pattern string) ([]model.Checksum, error)
+	Import(items []ImportItem, opts ImportOptions) ([]ImportResult, error)
+	Orphans() ([]model.Record, error)
+	Trash() ([]model.TrashItem, error)
+	Restore(name string, id int64) error
+	PurgeTrash(before time.Time) (int, error)
+	SetSchema(table, rules string) error
+	GetSchema(table string) (string, error)
+	Validate(pattern string) ([]schema.Violation, error)
+	WithTx(fn func(Repository) error) error
 	Close() error
 	Ping() error
 	GetPath() string
 }
 
-// InsertTable inserts a table record
+// InsertTable inserts a table record together with its records
+// Either the table and all its records are inserted, or nothing is
 func (db *DB) InsertTable(t *model.Table) error {
+	if err := validateName(t.FullName(), 2); err != nil {
+		return err
+	}
+	return db.withTx(func(tx *DB) error {
+		if err := tx.trackChange(t.FullName(), model.ActionInsert, func(tx *DB) error {
+			return tx.insertTable(t)
+		}); err != nil {
+			return err
+		}
+
+		// Adopt orphan records left under this name by repositories without foreign keys
+		if _, err := tx.Exec(`
+		UPDATE data_record SET table_name = ?1
+		WHERE table_name IS NULL AND substr(name, 1, length(?1) + 1) = ?1 || ':'
+		AND instr(substr(name, length(?1) + 2), ':') = 0
+		`, t.FullName()); err != nil {
+			return fmt.Errorf("failed to link records of %s: %w", t.FullName(), err)
+		}
+
+		// Insert associated records
+		for _, r := range t.Records {
+			if err := tx.InsertRecord(&r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insertTable writes the table row, its tags and attributes without recording history
+func (db *DB) insertTable(t *model.Table) error {
 	query := `
 	INSERT INTO data_table (
 		name, keys, path, engine, source, description,
@@ -38,49 +99,76 @@ func (db *DB) InsertTable(t *model.Table) error {
 		t.CreateAt, t.ModifyAt,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to insert table: %w", err)
+		return constraintError(fmt.Errorf("failed to insert table: %w", err), t.FullName())
 	}
-
-	// Insert associated records
-	for _, r := range t.Records {
-		if err := db.InsertRecord(&r); err != nil {
-			return err
-		}
+	if err := db.setTags(t.FullName(), t.Tags); err != nil {
+		return err
 	}
-	return nil
+	return db.setAttributes(t.FullName(), t.Attributes)
 }
 
 // InsertRecord inserts a regular record
 func (db *DB) InsertRecord(r *model.Record) error {
-	// First check if the table exists (Perl6 logic)
-	// Here we assume business logic handles this, or check by Name simply
-	// FullName format: database:table:name
+	if err := validateName(r.FullName(), 3); err != nil {
+		return err
+	}
+	return db.trackChange(r.FullName(), model.ActionInsert, func(tx *DB) error {
+		return tx.insertRecord(r)
+	})
+}
+
+// insertRecord writes the record row, its tags and attributes without recording history
+func (db *DB) insertRecord(r *model.Record) error {
+	// The parent table must exist; the foreign key enforces it as well,
+	// but checking first names the missing table in the error
+	parent := r.Database + ":" + r.Table
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM data_table WHERE name = ?", parent).Scan(&n); err != nil {
+		return fmt.Errorf("failed to look up table %s: %w", parent, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("cannot insert %s: %w", r.FullName(), notFound(parent))
+	}
+	if err := db.checkSchema(r); err != nil {
+		return err
+	}
 
 	query := `
 	INSERT INTO data_record (
-		name, type, source, label, description,
+		name, table_name, type, source, label, description,
 		number, missNumber, uniqueNumber,
 		script_file, script_tag, desc_file, desc_tag, log_file,
 		create_at, modify_at
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 	`
 	_, err := db.Exec(query,
-		r.FullName(), r.Type, r.Source, r.Label, r.Description,
+		r.FullName(), parent, r.Type, r.Source, r.Label, r.Description,
 		r.Number, r.MissNumber, r.UniqueNumber,
 		r.ScriptFile, r.ScriptTag, r.DescFile, r.DescTag, r.LogFile,
 		r.CreateAt, r.ModifyAt,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to insert record: %w", err)
+		return constraintError(fmt.Errorf("failed to insert record: %w", err), r.FullName())
+	}
+	if err := db.setTags(r.FullName(), r.Tags); err != nil {
+		return err
 	}
-	return nil
+	return db.setAttributes(r.FullName(), r.Attributes)
 }
 
-// GetTable retrieves a table by name
-func (db *DB) GetTable(name string) (*model.Table, error) {
-	query := `SELECT * FROM data_table WHERE name = ?`
-	row := db.QueryRow(query, name)
+// Column lists matching scanTable and scanRecord
+const (
+	tableColumns = `name, keys, path, engine, source, description,
+		script_file, script_tag, desc_file, desc_tag, log_file,
+		create_at, modify_at`
+	recordColumns = `name, type, source, label, description,
+		number, missNumber, uniqueNumber,
+		script_file, script_tag, desc_file, desc_tag, log_file,
+		create_at, modify_at`
+)
 
+// scanTable reads one data_table row selected with tableColumns
+func scanTable(row rowScanner) (*model.Table, error) {
 	var t model.Table
 	var fullName string
 	err := row.Scan(
@@ -88,11 +176,8 @@ func (db *DB) GetTable(name string) (*model.Table, error) {
 		&t.ScriptFile, &t.ScriptTag, &t.DescFile, &t.DescTag, &t.LogFile,
 		&t.CreateAt, &t.ModifyAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil // Not found
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan table: %w", err)
+		return nil, err
 	}
 
 	// Parse FullName into Database and Name
@@ -101,23 +186,11 @@ func (db *DB) GetTable(name string) (*model.Table, error) {
 		t.Database = parts[0]
 		t.Name = parts[1]
 	}
-
-	// Get associated records
-	// Associated records Name wildcard match: full_table_name:%
-	records, err := db.SearchRecords(fullName + ":%")
-	if err != nil {
-		return nil, err
-	}
-	t.Records = records
-
 	return &t, nil
 }
 
-// GetRecord retrieves a record by name
-func (db *DB) GetRecord(name string) (*model.Record, error) {
-	query := `SELECT * FROM data_record WHERE name = ?`
-	row := db.QueryRow(query, name)
-
+// scanRecord reads one data_record row selected with recordColumns
+func scanRecord(row rowScanner) (*model.Record, error) {
 	var r model.Record
 	var fullName string
 	err := row.Scan(
@@ -126,11 +199,8 @@ func (db *DB) GetRecord(name string) (*model.Record, error) {
 		&r.ScriptFile, &r.ScriptTag, &r.DescFile, &r.DescTag, &r.LogFile,
 		&r.CreateAt, &r.ModifyAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan record: %w", err)
+		return nil, err
 	}
 
 	parts := strings.Split(fullName, ":")
@@ -139,14 +209,83 @@ func (db *DB) GetRecord(name string) (*model.Record, error) {
 		r.Table = parts[1]
 		r.Name = parts[2]
 	}
-
 	return &r, nil
 }
 
+// GetTable retrieves a table by name
+// It returns ErrNotFound if there is no such table
+func (db *DB) GetTable(name string) (*model.Table, error) {
+	query := `SELECT ` + tableColumns + ` FROM data_table WHERE name = ?`
+	t, err := scanTable(db.QueryRow(query, name))
+	if err == sql.ErrNoRows {
+		return nil, notFound(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan table: %w", err)
+	}
+
+	if t.Tags, err = db.loadTags(name); err != nil {
+		return nil, err
+	}
+	if t.Attributes, err = db.loadAttributes(name); err != nil {
+		return nil, err
+	}
+
+	// Get associated records through their foreign key; a LIKE on the name would
+	// also match other tables, since _ is a wildcard and LIKE ignores case
+	records, err := db.queryRecords(`SELECT `+recordColumns+` FROM data_record WHERE table_name = ? ORDER BY name`, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load records of %s: %w", name, err)
+	}
+	t.Records = records
+
+	return t, nil
+}
+
+// GetRecord retrieves a record by name
+// It returns ErrNotFound if there is no such record
+func (db *DB) GetRecord(name string) (*model.Record, error) {
+	query := `SELECT ` + recordColumns + ` FROM data_record WHERE name = ?`
+	r, err := scanRecord(db.QueryRow(query, name))
+	if err == sql.ErrNoRows {
+		return nil, notFound(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan record: %w", err)
+	}
+
+	if r.Tags, err = db.loadTags(name); err != nil {
+		return nil, err
+	}
+	if r.Attributes, err = db.loadAttributes(name); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Orphans returns the records whose parent table does not exist, ordered by name
+// They predate the foreign key on data_record.table_name, which rejects new orphans
+func (db *DB) Orphans() ([]model.Record, error) {
+	query := `SELECT ` + recordColumns + ` FROM data_record
+	WHERE table_name IS NULL OR table_name NOT IN (SELECT name FROM data_table)
+	ORDER BY name`
+	records, err := db.queryRecords(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphan records: %w", err)
+	}
+	return records, nil
+}
+
 // SearchRecords searches records (simple LIKE implementation)
 func (db *DB) SearchRecords(pattern string) ([]model.Record, error) {
-	query := `SELECT * FROM data_record WHERE name LIKE ?`
-	rows, err := db.Query(query, pattern)
+	query := `SELECT ` + recordColumns + ` FROM data_record WHERE name LIKE ?`
+	return db.queryRecords(query, pattern)
+}
+
+// queryRecords runs a query selecting recordColumns and loads the tags and attributes of each record
+func (db *DB) queryRecords(query string, args ...any) ([]model.Record, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -154,55 +293,125 @@ func (db *DB) SearchRecords(pattern string) ([]model.Record, error) {
 
 	var records []model.Record
 	for rows.Next() {
-		var r model.Record
-		var fullName string
-		err := rows.Scan(
-			&fullName, &r.Type, &r.Source, &r.Label, &r.Description,
-			&r.Number, &r.MissNumber, &r.UniqueNumber,
-			&r.ScriptFile, &r.ScriptTag, &r.DescFile, &r.DescTag, &r.LogFile,
-			&r.CreateAt, &r.ModifyAt,
-		)
+		r, err := scanRecord(rows)
 		if err != nil {
 			return nil, err
 		}
-		parts := strings.Split(fullName, ":")
-		if len(parts) >= 3 {
-			r.Database = parts[0]
-			r.Table = parts[1]
-			r.Name = parts[2]
+		records = append(records, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if records[i].Tags, err = db.loadTags(records[i].FullName()); err != nil {
+			return nil, err
+		}
+		if records[i].Attributes, err = db.loadAttributes(records[i].FullName()); err != nil {
+			return nil, err
 		}
-		records = append(records, r)
 	}
 	return records, nil
 }
 
-// Delete removes a record or table
+// Delete moves a record or table to the trash, from where Restore brings it back
 // force: if it is a table, force remove all its records
+// Without force a table holding records fails with ErrHasChildren
+// The table, its records, tags, attributes and lineage are removed in one transaction
 func (db *DB) Delete(name string, force bool) error {
+	return db.withTx(func(tx *DB) error {
+		return tx.delete(name, force)
+	})
+}
+
+// delete removes a record or table within the caller's transaction
+func (db *DB) delete(name string, force bool) error {
 	// Try finding as table first
 	t, err := db.GetTable(name)
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrNotFound) {
 		return err
 	}
 	if t != nil {
-		if !force {
-			return fmt.Errorf("cannot delete table %s without force flag", name)
+		if !force && len(t.Records) > 0 {
+			return fmt.Errorf("%w: table %s has %d records (use force to delete them)", ErrHasChildren, name, len(t.Records))
+		}
+		if err := db.trashTable(t); err != nil {
+			return err
+		}
+		// Snapshot the table and its records for the audit trail
+		before := map[string][]byte{}
+		for _, n := range append([]string{name}, recordNames(t.Records)...) {
+			if before[n], err = db.snapshot(n); err != nil {
+				return err
+			}
 		}
+
 		// Delete all sub-records
-		// Roughly using LIKE here
-		if _, err := db.Exec("DELETE FROM data_record WHERE name LIKE ?", name+":%"); err != nil {
+		if _, err := db.Exec("DELETE FROM data_record WHERE table_name = ?", name); err != nil {
 			return err
 		}
 		// Delete table
 		if _, err := db.Exec("DELETE FROM data_table WHERE name = ?", name); err != nil {
 			return err
 		}
+		// Delete the tags, attributes, checksums and lineage edges of the table and its
+		// records, matched exactly like the trash captured them
+		for _, stmt := range []string{
+			"DELETE FROM data_tag WHERE " + matchItem("name"),
+			"DELETE FROM data_attribute WHERE " + matchItem("name"),
+			"DELETE FROM data_checksum WHERE " + matchItem("name"),
+			"DELETE FROM data_lineage WHERE " + matchItem("name") + " OR " + matchItem("upstream"),
+		} {
+			if _, err := db.Exec(stmt, name, true); err != nil {
+				return err
+			}
+		}
+
+		for n, b := range before {
+			if err := db.recordHistory(n, model.ActionDelete, b, nil); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
 	// Try finding as record and remove
-	if _, err := db.Exec("DELETE FROM data_record WHERE name = ?", name); err != nil {
+	r, err := db.GetRecord(name)
+	if err != nil {
 		return err
 	}
-	return nil
+	return db.trackChange(name, model.ActionDelete, func(tx *DB) error {
+		if err := tx.trashRecord(r); err != nil {
+			return err
+		}
+		res, err := tx.Exec("DELETE FROM data_record WHERE name = ?", name)
+		if err != nil {
+			return err
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return notFound(name)
+		}
+		if _, err := tx.Exec("DELETE FROM data_tag WHERE name = ?", name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM data_attribute WHERE name = ?", name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM data_lineage WHERE name = ? OR upstream = ?", name, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM data_checksum WHERE name = ?", name); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// recordNames returns the full names of records
+func recordNames(records []model.Record) []string {
+	names := make([]string, len(records))
+	for i := range records {
+		names[i] = records[i].FullName()
+	}
+	return names
 }
@@ -1,8 +1,11 @@
 package store
 
 import (
+	"errors"
 	"path/filepath"
 	"srdm/internal/model"
+	"srdm/internal/query"
+	"strings"
 	"testing"
 	"time"
 )
@@ -20,6 +23,14 @@ func setupTestDB(t *testing.T) *DB {
 	return db
 }
 
+// insertParent creates the empty parent table records need before they are inserted
+func insertParent(t *testing.T, db *DB, database, name string) {
+	t.Helper()
+	if err := db.InsertTable(&model.Table{Database: database, Name: name, Keys: "id"}); err != nil {
+		t.Fatalf("Failed to insert table %s:%s: %v", database, name, err)
+	}
+}
+
 func TestInsertAndGetTable(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -57,6 +68,7 @@ func TestInsertAndGetTable(t *testing.T) {
 func TestInsertAndGetRecord(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
+	insertParent(t, db, "db1", "tbl1")
 
 	record := &model.Record{
 		Database:    "db1",
@@ -90,6 +102,7 @@ func TestSearchRecords(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
 
+	insertParent(t, db, "db1", "tbl1")
 	records := []model.Record{
 		{Database: "db1", Table: "tbl1", Name: "rec_alpha", Type: "t1"},
 		{Database: "db1", Table: "tbl1", Name: "rec_beta", Type: "t1"},
@@ -172,3 +185,856 @@ func TestDelete(t *testing.T) {
 		t.Error("Child record should be deleted")
 	}
 }
+
+func TestTags(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db1", "tbl1")
+	record := &model.Record{
+		Database: "db1", Table: "tbl1", Name: "rec1",
+		Tags: []string{"cleaned", "paper-2025"},
+	}
+	if err := db.InsertRecord(record); err != nil {
+		t.Fatalf("InsertRecord failed: %v", err)
+	}
+	if err := db.AddTags("db1:tbl1:rec1", "confidential", "cleaned"); err != nil {
+		t.Fatalf("AddTags failed: %v", err)
+	}
+
+	r, _ := db.GetRecord("db1:tbl1:rec1")
+	if len(r.Tags) != 3 {
+		t.Errorf("Expected 3 tags, got %v", r.Tags)
+	}
+
+	if err := db.RemoveTags("db1:tbl1:rec1", "paper-2025"); err != nil {
+		t.Fatalf("RemoveTags failed: %v", err)
+	}
+	names, err := db.TaggedNames("paper-2025")
+	if err != nil {
+		t.Fatalf("TaggedNames failed: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("Expected no items tagged paper-2025, got %v", names)
+	}
+
+	if err := db.AddTags("db1:tbl1:missing", "x"); err == nil {
+		t.Error("Tagging a missing item should fail")
+	}
+
+	// Deleting the record drops its tags
+	db.Delete("db1:tbl1:rec1", false)
+	all, _ := db.ListTags("")
+	if len(all) != 0 {
+		t.Errorf("Expected no tags after delete, got %v", all)
+	}
+}
+
+func TestLineage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, name := range []string{"raw", "clean", "result"} {
+		if err := db.InsertTable(&model.Table{Database: "db1", Name: name, Keys: "id"}); err != nil {
+			t.Fatalf("InsertTable failed: %v", err)
+		}
+	}
+	edges := []model.LineageEdge{
+		{Name: "db1:clean", Upstream: "db1:raw", Relation: model.RelationDerivedFrom},
+		{Name: "db1:result", Upstream: "db1:clean", Relation: model.RelationDerivedFrom},
+		{Name: "db1:result", Upstream: "analysis.R", Relation: model.RelationProducedByScript},
+	}
+	for _, e := range edges {
+		if err := db.AddLineage(&e); err != nil {
+			t.Fatalf("AddLineage failed: %v", err)
+		}
+	}
+
+	up, err := db.Lineage("db1:result", false)
+	if err != nil {
+		t.Fatalf("Lineage failed: %v", err)
+	}
+	if len(up) != 3 {
+		t.Errorf("Expected 3 upstream edges, got %d", len(up))
+	}
+
+	down, err := db.Lineage("db1:raw", true)
+	if err != nil {
+		t.Fatalf("Lineage failed: %v", err)
+	}
+	if len(down) != 2 {
+		t.Errorf("Expected 2 downstream edges, got %d", len(down))
+	}
+
+	bad := model.LineageEdge{Name: "db1:clean", Upstream: "db1:missing", Relation: model.RelationDerivedFrom}
+	if err := db.AddLineage(&bad); err == nil {
+		t.Error("Lineage to a missing upstream should fail")
+	}
+}
+
+func TestHistoryAndRevert(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db1", "tbl1")
+	record := &model.Record{Database: "db1", Table: "tbl1", Name: "rec1", Label: "v1"}
+	if err := db.InsertRecord(record); err != nil {
+		t.Fatalf("InsertRecord failed: %v", err)
+	}
+	record.Label = "v2"
+	if err := db.UpdateRecord(record); err != nil {
+		t.Fatalf("UpdateRecord failed: %v", err)
+	}
+	if err := db.Delete("db1:tbl1:rec1", false); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	entries, err := db.History("db1:tbl1:rec1")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	actions := []string{model.ActionInsert, model.ActionUpdate, model.ActionDelete}
+	if len(entries) != len(actions) {
+		t.Fatalf("Expected %d history entries, got %d", len(actions), len(entries))
+	}
+	for i, e := range entries {
+		if e.Action != actions[i] || e.Version != i+1 || e.User == "" {
+			t.Errorf("Unexpected entry %d: %+v", i, e)
+		}
+	}
+	if entries[2].After != nil {
+		t.Error("Delete entry should have no after state")
+	}
+
+	// Deleted record is re-created with the label of version 1
+	if err := db.Revert("db1:tbl1:rec1", 1); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	r, _ := db.GetRecord("db1:tbl1:rec1")
+	if r == nil || r.Label != "v1" {
+		t.Fatalf("Expected reverted label v1, got %+v", r)
+	}
+
+	if err := db.Revert("db1:tbl1:rec1", 3); err == nil {
+		t.Error("Reverting to a deletion should fail")
+	}
+
+	entries, _ = db.History("db1:tbl1:rec1")
+	if last := entries[len(entries)-1]; last.Action != model.ActionRevert {
+		t.Errorf("Expected revert entry, got %s", last.Action)
+	}
+}
+
+func TestSearchText(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	if !db.textSearch {
+		t.Skip("SQLite built without FTS5; run with -tags sqlite_fts5")
+	}
+
+	db.InsertTable(&model.Table{Database: "survey", Name: "hh", Keys: "id", Description: "Household income panel 2010-2020"})
+	db.InsertRecord(&model.Record{Database: "survey", Table: "hh", Name: "inc", Description: "Annual household income", Label: "income"})
+	db.InsertRecord(&model.Record{Database: "survey", Table: "hh", Name: "age", Description: "Age of the respondent"})
+
+	hits, err := db.SearchText("household income", 0)
+	if err != nil {
+		t.Fatalf("SearchText failed: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %+v", hits)
+	}
+	if !strings.Contains(hits[0].Snippet, HighlightStart) {
+		t.Errorf("Snippet not highlighted: %q", hits[0].Snippet)
+	}
+
+	// Triggers keep the index in sync with updates and deletes
+	r, _ := db.GetRecord("survey:hh:age")
+	r.Description = "Age in household roster"
+	db.UpdateRecord(r)
+	db.Delete("survey:hh:inc", false)
+
+	hits, _ = db.SearchText("household", 0)
+	if len(hits) != 2 {
+		t.Errorf("Expected table and updated record, got %+v", hits)
+	}
+	hits, _ = db.SearchText("annual", 0)
+	if len(hits) != 0 {
+		t.Errorf("Deleted record still indexed: %+v", hits)
+	}
+}
+
+func TestFilterRecords(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "bio", "seq")
+	insertParent(t, db, "bio", "old")
+	records := []model.Record{
+		{Database: "bio", Table: "seq", Name: "s1", Type: "fastq", Number: 5000, Label: "Control group", ModifyAt: time.Now()},
+		{Database: "bio", Table: "seq", Name: "s2", Type: "fastq", Number: 10, Label: "control", ModifyAt: time.Now()},
+		{Database: "bio", Table: "seq", Name: "s3", Type: "bam", Number: 9000, Label: "treated", ModifyAt: time.Now()},
+		{Database: "bio", Table: "old", Name: "s4", Type: "fastq", Number: 2000, Label: "control", ModifyAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, r := range records {
+		if err := db.InsertRecord(&r); err != nil {
+			t.Fatalf("InsertRecord failed: %v", err)
+		}
+	}
+
+	f, err := query.Compile("type=fastq AND number>1000 AND modified>2025-01-01 AND label~control", query.Records)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	results, err := db.FilterRecords(Query{Where: f.Where, Args: f.Args})
+	if err != nil {
+		t.Fatalf("FilterRecords failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "s1" {
+		t.Errorf("Expected only s1, got %+v", results)
+	}
+
+	f, _ = query.Compile("table=seq", query.Records)
+	order, _ := query.Records.OrderBy("-number")
+	results, err = db.FilterRecords(Query{Where: f.Where, Args: f.Args, OrderBy: order, Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("FilterRecords failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "s1" || results[1].Name != "s2" {
+		t.Errorf("Unexpected page: %+v", results)
+	}
+
+	// Times stored with a zone compare by instant, not by their wall-clock text
+	if _, err := db.Exec("UPDATE data_record SET modify_at = '2025-01-01 23:30:00-05:00' WHERE name = 'bio:old:s4'"); err != nil {
+		t.Fatal(err)
+	}
+	for expr, want := range map[string]int{
+		"table=old AND modified>2025-01-02T04:00:00Z":      1,
+		"table=old AND modified>2025-01-02T05:00:00Z":      0,
+		"table=old AND modified<2025-01-02T06:00:00+01:00": 1,
+	} {
+		f, err := query.Compile(expr, query.Records)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", expr, err)
+		}
+		results, err := db.FilterRecords(Query{Where: f.Where, Args: f.Args})
+		if err != nil {
+			t.Fatalf("FilterRecords failed: %v", err)
+		}
+		if len(results) != want {
+			t.Errorf("%s: got %d results, want %d", expr, len(results), want)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db", "t")
+	if err := db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "old", Label: "before"}); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	items := func() []ImportItem {
+		return []ImportItem{
+			{Row: 1, Table: &model.Table{Database: "db", Name: "u", Keys: "id"}},
+			{Row: 2, Record: &model.Record{Database: "db", Table: "t", Name: "old", Label: "after"}},
+			{Row: 3, Record: &model.Record{Database: "db", Table: "t", Name: "new", Tags: []string{"raw"}}},
+		}
+	}
+
+	// A conflict fails the whole import
+	results, err := db.Import(items(), ImportOptions{OnConflict: ConflictFail})
+	if !errors.Is(err, ErrImportFailed) {
+		t.Fatalf("Expected ErrImportFailed, got %v", err)
+	}
+	if results[1].Action != ImportFailed || results[1].Err == nil || results[0].Action != ImportInserted {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if tbl, _ := db.GetTable("db:u"); tbl != nil {
+		t.Error("Failed import should roll back the inserted table")
+	}
+
+	// Dry run reports but writes nothing
+	if _, err := db.Import(items(), ImportOptions{OnConflict: ConflictUpdate, DryRun: true}); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if r, _ := db.GetRecord("db:t:new"); r != nil {
+		t.Error("Dry run should not write")
+	}
+
+	results, err = db.Import(items(), ImportOptions{OnConflict: ConflictSkip})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if results[1].Action != ImportSkipped {
+		t.Errorf("Expected skip, got %s", results[1].Action)
+	}
+	if r, _ := db.GetRecord("db:t:old"); r.Label != "before" {
+		t.Errorf("Skipped record changed: %s", r.Label)
+	}
+	if r, _ := db.GetRecord("db:t:new"); r == nil || len(r.Tags) != 1 || r.CreateAt.IsZero() {
+		t.Errorf("New record not imported correctly: %+v", r)
+	}
+
+	results, err = db.Import(items()[1:2], ImportOptions{OnConflict: ConflictUpdate})
+	if err != nil || results[0].Action != ImportUpdated {
+		t.Fatalf("Update import failed: %v %+v", err, results)
+	}
+	if r, _ := db.GetRecord("db:t:old"); r.Label != "after" {
+		t.Errorf("Expected updated label, got %s", r.Label)
+	}
+}
+
+func TestWithTx(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// A table whose records collide fails as a whole
+	table := &model.Table{
+		Database: "db", Name: "t", Keys: "id",
+		Records: []model.Record{
+			{Database: "db", Table: "t", Name: "a"},
+			{Database: "db", Table: "t", Name: "a"},
+		},
+	}
+	if err := db.InsertTable(table); err == nil {
+		t.Fatal("Expected duplicate record error")
+	}
+	if got, _ := db.GetTable("db:t"); got != nil {
+		t.Error("Table should not exist after a failed insert")
+	}
+	if h, _ := db.History("db:t"); len(h) != 0 {
+		t.Errorf("Failed insert should leave no history, got %d entries", len(h))
+	}
+
+	// Returning an error rolls back everything done through the transaction
+	errStop := errors.New("stop")
+	err := db.WithTx(func(repo Repository) error {
+		if err := repo.InsertTable(&model.Table{Database: "db", Name: "t", Keys: "id"}); err != nil {
+			return err
+		}
+		if err := repo.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a"}); err != nil {
+			return err
+		}
+		if r, _ := repo.GetRecord("db:t:a"); r == nil {
+			t.Error("Record should be visible inside the transaction")
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Expected errStop, got %v", err)
+	}
+	if r, _ := db.GetRecord("db:t:a"); r != nil {
+		t.Error("Record should be rolled back")
+	}
+
+	if err := db.WithTx(func(repo Repository) error {
+		return repo.InsertTable(&model.Table{Database: "db", Name: "t", Keys: "id", Records: []model.Record{{Database: "db", Table: "t", Name: "a"}}})
+	}); err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+	if r, _ := db.GetRecord("db:t:a"); r == nil {
+		t.Error("Committed record missing")
+	}
+}
+
+func TestChecksums(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	db.InsertTable(&model.Table{Database: "db", Name: "t", Keys: "id"})
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a"})
+	sums := []*model.Checksum{
+		{Name: "db:t", Field: model.FileFieldPath, Path: "/data/t.sqlite", Size: 1, SHA256: "aa"},
+		{Name: "db:t:a", Field: model.FileFieldSource, Path: "/data/a.csv", Size: 2, SHA256: "bb"},
+	}
+	for _, c := range sums {
+		if err := db.SaveChecksum(c); err != nil {
+			t.Fatalf("SaveChecksum failed: %v", err)
+		}
+	}
+
+	// Saving again replaces the stored state
+	sums[0].SHA256 = "cc"
+	db.SaveChecksum(sums[0])
+	got, err := db.Checksums("db:t")
+	if err != nil {
+		t.Fatalf("Checksums failed: %v", err)
+	}
+	if len(got) != 2 || got[0].SHA256 != "cc" || got[1].Path != "/data/a.csv" {
+		t.Errorf("Unexpected checksums: %+v", got)
+	}
+
+	// Checksum matches the name exactly, glob characters included
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "wave[1].csv"})
+	db.SaveChecksum(&model.Checksum{Name: "db:t:wave[1].csv", Field: model.FileFieldSource, Path: "/data/wave[1].csv", SHA256: "dd"})
+	if c, err := db.Checksum("db:t:wave[1].csv", model.FileFieldSource); err != nil || c.SHA256 != "dd" {
+		t.Errorf("Checksum: got %+v, %v", c, err)
+	}
+	if _, err := db.Checksum("db:t:a", model.FileFieldPath); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Checksum: expected ErrNotFound, got %v", err)
+	}
+
+	if err := db.Delete("db:t", true); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := db.Checksums("*"); len(got) != 0 {
+		t.Errorf("Delete should remove checksums, got %+v", got)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if _, err := db.GetTable("db:missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTable: expected ErrNotFound, got %v", err)
+	}
+	if _, err := db.GetRecord("db:t:missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRecord: expected ErrNotFound, got %v", err)
+	}
+	if err := db.UpdateRecord(&model.Record{Database: "db", Table: "t", Name: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("UpdateRecord: expected ErrNotFound, got %v", err)
+	}
+	if err := db.Delete("db:t:missing", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete: expected ErrNotFound, got %v", err)
+	}
+
+	table := &model.Table{Database: "db", Name: "t", Keys: "id"}
+	db.InsertTable(table)
+	if err := db.InsertTable(table); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("InsertTable: expected ErrAlreadyExists, got %v", err)
+	}
+	record := &model.Record{Database: "db", Table: "t", Name: "r"}
+	db.InsertRecord(record)
+	if err := db.InsertRecord(record); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("InsertRecord: expected ErrAlreadyExists, got %v", err)
+	}
+
+	if err := db.Delete("db:t", false); !errors.Is(err, ErrHasChildren) {
+		t.Errorf("Delete: expected ErrHasChildren, got %v", err)
+	}
+	// An empty table needs no force
+	db.InsertTable(&model.Table{Database: "db", Name: "empty", Keys: "id"})
+	if err := db.Delete("db:empty", false); err != nil {
+		t.Errorf("Delete of an empty table failed: %v", err)
+	}
+
+	for _, r := range []*model.Record{
+		{Database: "db", Table: "", Name: "r"},
+		{Database: "db", Table: "t", Name: "a:b"},
+	} {
+		if err := db.InsertRecord(r); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("InsertRecord(%s): expected ErrInvalidName, got %v", r.FullName(), err)
+		}
+	}
+	if _, err := SplitName("db"); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("SplitName: expected ErrInvalidName, got %v", err)
+	}
+}
+
+func TestRecordParent(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if err := db.InsertRecord(&model.Record{Database: "db", Table: "typo", Name: "r"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Orphan insert: expected ErrNotFound, got %v", err)
+	}
+
+	insertParent(t, db, "db", "t")
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "r"})
+
+	// An orphan left by an older version is adopted when its table is created
+	if _, err := db.Exec(`INSERT INTO data_record (name, type, source, label, description,
+		number, missNumber, uniqueNumber, script_file, script_tag, desc_file, desc_tag, log_file)
+		VALUES ('db:u:o', '', '', '', '', 0, 0, 0, '', '', '', '', '')`); err != nil {
+		t.Fatal(err)
+	}
+	if orphans, _ := db.Orphans(); len(orphans) != 1 {
+		t.Fatalf("Expected one orphan, got %+v", orphans)
+	}
+	insertParent(t, db, "db", "u")
+	if orphans, _ := db.Orphans(); len(orphans) != 0 {
+		t.Errorf("Orphan should be adopted by its new table, got %+v", orphans)
+	}
+
+	// Deleting the table removes its records through the cascade
+	if _, err := db.Exec("DELETE FROM data_table WHERE name = 'db:u'"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.GetRecord("db:u:o"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected cascade delete of db:u:o, got %v", err)
+	}
+}
+
+// insertLookalikes creates tables whose names a LIKE 'db:a_b:%' would confuse,
+// each with one record r carrying a tag, an attribute and a checksum
+func insertLookalikes(t *testing.T, db *DB) []string {
+	t.Helper()
+	tables := []string{"db:a_b", "db:aXb", "db:A_B"}
+	for _, name := range tables {
+		insertParent(t, db, "db", name[3:])
+		r := &model.Record{Database: "db", Table: name[3:], Name: "r", Tags: []string{"raw"}, Attributes: map[string]string{"unit": "kg"}}
+		if err := db.InsertRecord(r); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveChecksum(&model.Checksum{Name: name + ":r", Field: "source", Path: name + ".csv", SHA256: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tables
+}
+
+func TestGetTableRecordsByForeignKey(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	for _, name := range insertLookalikes(t, db) {
+		table, err := db.GetTable(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(table.Records) != 1 || table.Records[0].FullName() != name+":r" {
+			t.Errorf("%s: expected only its own record, got %+v", name, table.Records)
+		}
+	}
+}
+
+func TestRenameLeavesLookalikes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertLookalikes(t, db)
+	db.AddLineage(&model.LineageEdge{Name: "db:A_B:r", Upstream: "db:aXb:r", Relation: model.RelationDerivedFrom})
+	if err := db.Rename("db:a_b", "db:c"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	for _, name := range []string{"db:c:r", "db:aXb:r", "db:A_B:r"} {
+		r, err := db.GetRecord(name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(r.Tags) != 1 || r.Attributes["unit"] != "kg" {
+			t.Errorf("%s lost its tags or attributes: %+v", name, r)
+		}
+		if sums, _ := db.Checksums(name); len(sums) != 1 {
+			t.Errorf("%s: expected its checksum, got %+v", name, sums)
+		}
+	}
+	if edges, _ := db.Lineage("db:A_B:r", false); len(edges) != 1 || edges[0].Upstream != "db:aXb:r" {
+		t.Errorf("Lineage of other tables changed: %+v", edges)
+	}
+}
+
+func TestDeleteLeavesLookalikes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertLookalikes(t, db)
+	db.AddLineage(&model.LineageEdge{Name: "db:A_B:r", Upstream: "db:aXb:r", Relation: model.RelationDerivedFrom})
+	if err := db.Delete("db:a_b", true); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	for _, name := range []string{"db:aXb:r", "db:A_B:r"} {
+		r, err := db.GetRecord(name)
+		if err != nil {
+			t.Fatalf("%s deleted with db:a_b: %v", name, err)
+		}
+		if len(r.Tags) != 1 || r.Attributes["unit"] != "kg" {
+			t.Errorf("%s lost its tags or attributes: %+v", name, r)
+		}
+		if sums, _ := db.Checksums(name); len(sums) != 1 {
+			t.Errorf("%s lost its checksum: %+v", name, sums)
+		}
+	}
+	if edges, _ := db.Lineage("db:A_B:r", false); len(edges) != 1 {
+		t.Errorf("Lineage of other tables deleted: %+v", edges)
+	}
+
+	// The trash holds db:a_b with only its own record, which comes back whole
+	if err := db.Restore("db:a_b", 0); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	table, err := db.GetTable("db:a_b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Records) != 1 || len(table.Records[0].Tags) != 1 || table.Records[0].Attributes["unit"] != "kg" {
+		t.Errorf("Restored table incomplete: %+v", table.Records)
+	}
+	if sums, _ := db.Checksums("db:a_b"); len(sums) != 1 {
+		t.Errorf("Restored checksums: %+v", sums)
+	}
+}
+
+func TestRename(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db", "t")
+	insertParent(t, db, "db", "other")
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a", Label: "A"})
+	db.InsertRecord(&model.Record{Database: "db", Table: "other", Name: "b"})
+	db.AddTags("db:t:a", "raw")
+	db.AddLineage(&model.LineageEdge{Name: "db:other:b", Upstream: "db:t:a", Relation: model.RelationDerivedFrom})
+	db.SaveChecksum(&model.Checksum{Name: "db:t:a", Field: "source", Path: "a.csv", SHA256: "x"})
+
+	if err := db.Rename("db:t", "new:t2"); err != nil {
+		t.Fatalf("Rename table failed: %v", err)
+	}
+	if _, err := db.GetTable("db:t"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Old table still exists: %v", err)
+	}
+	r, err := db.GetRecord("new:t2:a")
+	if err != nil {
+		t.Fatalf("Record did not follow its table: %v", err)
+	}
+	if r.Label != "A" || len(r.Tags) != 1 || r.Tags[0] != "raw" {
+		t.Errorf("Record lost its fields or tags: %+v", r)
+	}
+	if edges, _ := db.Lineage("db:other:b", false); len(edges) != 1 || edges[0].Upstream != "new:t2:a" {
+		t.Errorf("Lineage not renamed: %+v", edges)
+	}
+	if sums, _ := db.Checksums("new:t2:a"); len(sums) != 1 {
+		t.Errorf("Checksum not renamed: %+v", sums)
+	}
+	entries, _ := db.History("new:t2:a")
+	if n := len(entries); n != 3 || entries[n-1].Action != model.ActionRename {
+		t.Errorf("Expected insert, tag and rename in history, got %+v", entries)
+	}
+
+	// Move the record to another table, then revert it to its first version
+	if err := db.Rename("new:t2:a", "db:other:a"); err != nil {
+		t.Fatalf("Move record failed: %v", err)
+	}
+	db.UpdateRecord(&model.Record{Database: "db", Table: "other", Name: "a", Label: "changed"})
+	if err := db.Revert("db:other:a", 1); err != nil {
+		t.Fatalf("Revert after rename failed: %v", err)
+	}
+	if r, _ := db.GetRecord("db:other:a"); r == nil || r.Label != "A" {
+		t.Errorf("Revert did not restore the label under the new name: %+v", r)
+	}
+
+	if err := db.Rename("db:other:a", "db:other:b"); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists, got %v", err)
+	}
+	if err := db.Rename("db:other:a", "db:missing:a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing parent, got %v", err)
+	}
+	if err := db.Rename("db:other", "db:other:x"); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db", "t")
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a",
+		Attributes: map[string]string{"license": "CC-BY", "year": "2021"}})
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "b",
+		Attributes: map[string]string{"license": "MIT", "year": "2019"}})
+
+	r, err := db.GetRecord("db:t:a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Attributes["license"] != "CC-BY" || r.Attributes["year"] != "2021" {
+		t.Errorf("Unexpected attributes: %v", r.Attributes)
+	}
+
+	f, err := query.Compile("attr.year>=2020 OR attr.license~mi", query.Records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := db.FilterRecords(Query{Where: f.Where, Args: f.Args})
+	if err != nil {
+		t.Fatalf("FilterRecords failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Errorf("Expected both records, got %d", len(records))
+	}
+	f, _ = query.Compile("attr.license!=MIT", query.Records)
+	if records, _ = db.FilterRecords(Query{Where: f.Where, Args: f.Args}); len(records) != 1 || records[0].Name != "a" {
+		t.Errorf("Expected only db:t:a, got %+v", records)
+	}
+
+	// Updates replace the attribute set and are recorded in the history
+	r.Attributes = map[string]string{"license": "CC0"}
+	if err := db.UpdateRecord(r); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ = db.GetRecord("db:t:a"); len(r.Attributes) != 1 || r.Attributes["license"] != "CC0" {
+		t.Errorf("Unexpected attributes after update: %v", r.Attributes)
+	}
+	if entries, _ := db.History("db:t:a"); len(entries) != 2 {
+		t.Errorf("Expected the attribute change in the history, got %d entries", len(entries))
+	}
+
+	if err := db.Rename("db:t:a", "db:t:c"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ = db.GetRecord("db:t:c"); r.Attributes["license"] != "CC0" {
+		t.Errorf("Attributes did not follow the rename: %v", r.Attributes)
+	}
+
+	if err := db.Delete("db:t", true); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	db.QueryRow("SELECT COUNT(*) FROM data_attribute").Scan(&n)
+	if n != 0 {
+		t.Errorf("Expected attributes to be deleted, %d left", n)
+	}
+}
+
+func TestSchemaValidation(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db", "t")
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "old", Type: "date"})
+
+	if err := db.SetSchema("db:missing", "required units"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := db.SetSchema("db:t", "type of int"); err == nil {
+		t.Error("Expected a parse error")
+	}
+	if err := db.SetSchema("db:t", "required units\ntype in int|float"); err != nil {
+		t.Fatalf("SetSchema failed: %v", err)
+	}
+
+	err := db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a", Type: "int"})
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Expected ErrSchemaViolation on insert, got %v", err)
+	}
+	ok := &model.Record{Database: "db", Table: "t", Name: "a", Type: "int", Attributes: map[string]string{"units": "kg"}}
+	if err := db.InsertRecord(ok); err != nil {
+		t.Fatalf("Valid insert failed: %v", err)
+	}
+	ok.Type = "text"
+	if err := db.UpdateRecord(ok); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("Expected ErrSchemaViolation on update, got %v", err)
+	}
+
+	// Records written before the schema are reported by Validate
+	violations, err := db.Validate("%")
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(violations) != 2 || violations[0].Name != "db:t:old" {
+		t.Errorf("Expected two violations of db:t:old, got %+v", violations)
+	}
+
+	// The schema follows its table
+	if err := db.Rename("db:t", "db:u"); err != nil {
+		t.Fatal(err)
+	}
+	if rules, _ := db.GetSchema("db:u"); rules == "" {
+		t.Error("Schema did not follow the renamed table")
+	}
+	if err := db.SetSchema("db:u", ""); err != nil {
+		t.Fatal(err)
+	}
+	if violations, _ := db.Validate("%"); len(violations) != 0 {
+		t.Errorf("Expected no violations without a schema, got %+v", violations)
+	}
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	insertParent(t, db, "db", "t")
+	insertParent(t, db, "db", "u")
+	db.InsertRecord(&model.Record{Database: "db", Table: "t", Name: "a", Label: "A",
+		Tags: []string{"raw"}, Attributes: map[string]string{"units": "kg"}})
+	db.InsertRecord(&model.Record{Database: "db", Table: "u", Name: "b"})
+	db.AddLineage(&model.LineageEdge{Name: "db:u:b", Upstream: "db:t:a", Relation: model.RelationDerivedFrom})
+	db.SaveChecksum(&model.Checksum{Name: "db:t:a", Field: "source", Path: "a.csv", SHA256: "x"})
+	db.SetSchema("db:t", "required units")
+
+	if err := db.Delete("db:t", true); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	// Trashed items are hidden from normal queries
+	if _, err := db.GetRecord("db:t:a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Trashed record still visible: %v", err)
+	}
+	if records, _ := db.FilterRecords(Query{}); len(records) != 1 {
+		t.Errorf("Expected only db:u:b, got %+v", records)
+	}
+	items, err := db.Trash()
+	if err != nil || len(items) != 1 || items[0].Name != "db:t" || items[0].Records != 1 {
+		t.Fatalf("Unexpected trash: %+v, %v", items, err)
+	}
+
+	if err := db.Restore("db:t", 0); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	r, err := db.GetRecord("db:t:a")
+	if err != nil {
+		t.Fatalf("Record not restored: %v", err)
+	}
+	if r.Label != "A" || len(r.Tags) != 1 || r.Attributes["units"] != "kg" {
+		t.Errorf("Record restored without its fields: %+v", r)
+	}
+	if edges, _ := db.Lineage("db:u:b", false); len(edges) != 1 {
+		t.Errorf("Lineage not restored: %+v", edges)
+	}
+	if sums, _ := db.Checksums("db:t:a"); len(sums) != 1 {
+		t.Errorf("Checksum not restored: %+v", sums)
+	}
+	if rules, _ := db.GetSchema("db:t"); rules != "required units" {
+		t.Errorf("Schema not restored: %q", rules)
+	}
+	if entries, _ := db.History("db:t:a"); entries[len(entries)-1].Action != model.ActionRestore {
+		t.Errorf("Expected a restore entry in the history, got %+v", entries)
+	}
+	if items, _ := db.Trash(); len(items) != 0 {
+		t.Errorf("Restored item still in the trash: %+v", items)
+	}
+
+	// A record cannot come back over a new item of the same name
+	db.Delete("db:u:b", false)
+	db.InsertRecord(&model.Record{Database: "db", Table: "u", Name: "b"})
+	if err := db.Restore("db:u:b", 0); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists, got %v", err)
+	}
+	if err := db.Restore("db:nothing", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if n, err := db.PurgeTrash(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("Expected nothing older than an hour, purged %d (%v)", n, err)
+	}
+	if n, err := db.PurgeTrash(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("Expected one purged item, got %d (%v)", n, err)
+	}
+}
+
+func TestPurgeTrashComparesInstants(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	// The same instant written in two zones, and an hour later
+	for _, at := range []string{"2024-05-01T10:00:00+02:00", "2024-05-01T08:00:00Z", "2024-05-01T09:00:00Z"} {
+		if _, err := db.Exec(
+			"INSERT INTO data_trash (name, kind, records, content, user, deleted_at) VALUES ('db:t', 'table', 0, '{}', '', ?)", at,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	if n, err := db.PurgeTrash(before); err != nil || n != 2 {
+		t.Errorf("Expected two purged entries, got %d (%v)", n, err)
+	}
+	if items, _ := db.Trash(); len(items) != 1 {
+		t.Errorf("Expected one entry left, got %+v", items)
+	}
+}
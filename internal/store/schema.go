@@ -0,0 +1,112 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"srdm/internal/model"
+	"srdm/internal/schema"
+	"strings"
+)
+
+// SetSchema replaces the validation rules of a table's records
+// Empty rules remove the schema. Existing records are not checked; use Validate
+func (db *DB) SetSchema(table, rules string) error {
+	if _, err := schema.Parse(rules); err != nil {
+		return err
+	}
+	return db.withTx(func(tx *DB) error {
+		var n int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM data_table WHERE name = ?", table).Scan(&n); err != nil {
+			return fmt.Errorf("failed to look up table %s: %w", table, err)
+		}
+		if n == 0 {
+			return notFound(table)
+		}
+
+		if strings.TrimSpace(rules) == "" {
+			if _, err := tx.Exec("DELETE FROM data_schema WHERE table_name = ?", table); err != nil {
+				return fmt.Errorf("failed to remove schema of %s: %w", table, err)
+			}
+			return nil
+		}
+		if _, err := tx.Exec(`
+		INSERT INTO data_schema (table_name, rules) VALUES (?, ?)
+		ON CONFLICT (table_name) DO UPDATE SET rules = excluded.rules, modify_at = DATETIME('NOW', 'LOCALTIME')
+		`, table, rules); err != nil {
+			return fmt.Errorf("failed to save schema of %s: %w", table, err)
+		}
+		return nil
+	})
+}
+
+// GetSchema returns the validation rules of a table, or "" if it has none
+func (db *DB) GetSchema(table string) (string, error) {
+	var rules string
+	err := db.QueryRow("SELECT rules FROM data_schema WHERE table_name = ?", table).Scan(&rules)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema of %s: %w", table, err)
+	}
+	return rules, nil
+}
+
+// Validate checks the records whose full name matches a LIKE pattern
+// against the schemas of their tables, returning every violation
+func (db *DB) Validate(pattern string) ([]schema.Violation, error) {
+	records, err := db.queryRecords(`SELECT `+recordColumns+` FROM data_record
+	WHERE table_name IN (SELECT table_name FROM data_schema) AND name LIKE ?
+	ORDER BY name`, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select records to validate: %w", err)
+	}
+
+	schemas := map[string]*schema.Schema{}
+	var out []schema.Violation
+	for i := range records {
+		r := &records[i]
+		parent := r.Database + ":" + r.Table
+		s, ok := schemas[parent]
+		if !ok {
+			if s, err = db.tableSchema(parent); err != nil {
+				return nil, err
+			}
+			schemas[parent] = s
+		}
+		if s != nil {
+			out = append(out, s.Check(r)...)
+		}
+	}
+	return out, nil
+}
+
+// checkSchema fails with ErrSchemaViolation if r breaks the schema of its table
+func (db *DB) checkSchema(r *model.Record) error {
+	s, err := db.tableSchema(r.Database + ":" + r.Table)
+	if err != nil || s == nil {
+		return err
+	}
+	violations := s.Check(r)
+	if len(violations) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = v.Message
+	}
+	return fmt.Errorf("%w: %s: %s", ErrSchemaViolation, r.FullName(), strings.Join(msgs, "; "))
+}
+
+// tableSchema returns the parsed schema of a table, or nil if it has none
+func (db *DB) tableSchema(table string) (*schema.Schema, error) {
+	rules, err := db.GetSchema(table)
+	if err != nil || rules == "" {
+		return nil, err
+	}
+	s, err := schema.Parse(rules)
+	if err != nil {
+		return nil, fmt.Errorf("schema of %s: %w", table, err)
+	}
+	return s, nil
+}
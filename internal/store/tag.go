@@ -0,0 +1,144 @@
+package store
+
+import (
+	"fmt"
+	"srdm/internal/model"
+	"strings"
+)
+
+// AddTags attaches tags to a table or record
+// Tags already present are ignored
+func (db *DB) AddTags(name string, tags ...string) error {
+	return db.withTx(func(tx *DB) error {
+		if err := tx.requireItem(name); err != nil {
+			return err
+		}
+		return tx.trackChange(name, model.ActionUpdate, func(tx *DB) error {
+			for _, tag := range NormalizeTags(tags) {
+				if _, err := tx.Exec(
+					"INSERT OR IGNORE INTO data_tag (name, tag) VALUES (?, ?)", name, tag,
+				); err != nil {
+					return fmt.Errorf("failed to add tag %s: %w", tag, err)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// RemoveTags detaches tags from a table or record
+// Tags not present are ignored
+func (db *DB) RemoveTags(name string, tags ...string) error {
+	return db.withTx(func(tx *DB) error {
+		if err := tx.requireItem(name); err != nil {
+			return err
+		}
+		return tx.trackChange(name, model.ActionUpdate, func(tx *DB) error {
+			for _, tag := range NormalizeTags(tags) {
+				if _, err := tx.Exec(
+					"DELETE FROM data_tag WHERE name = ? AND tag = ?", name, tag,
+				); err != nil {
+					return fmt.Errorf("failed to remove tag %s: %w", tag, err)
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// ListTags returns the sorted tags of a table or record
+// An empty name lists every distinct tag in the repository
+func (db *DB) ListTags(name string) ([]string, error) {
+	if name == "" {
+		return db.queryStrings("SELECT DISTINCT tag FROM data_tag ORDER BY tag")
+	}
+	return db.loadTags(name)
+}
+
+// TaggedNames returns the full names of tables and records carrying any of the tags
+func (db *DB) TaggedNames(tags ...string) ([]string, error) {
+	tags = NormalizeTags(tags)
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(tags)), ",")
+	args := make([]any, len(tags))
+	for i, tag := range tags {
+		args[i] = tag
+	}
+	return db.queryStrings(
+		"SELECT DISTINCT name FROM data_tag WHERE tag IN ("+placeholders+") ORDER BY name",
+		args...,
+	)
+}
+
+// NormalizeTags trims whitespace, drops empty entries and removes duplicates
+// while preserving the original order
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var out []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// setTags replaces the tag set of a table or record
+func (db *DB) setTags(name string, tags []string) error {
+	if _, err := db.Exec("DELETE FROM data_tag WHERE name = ?", name); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+	for _, tag := range NormalizeTags(tags) {
+		if _, err := db.Exec(
+			"INSERT INTO data_tag (name, tag) VALUES (?, ?)", name, tag,
+		); err != nil {
+			return fmt.Errorf("failed to set tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// loadTags returns the sorted tags attached to a single name
+func (db *DB) loadTags(name string) ([]string, error) {
+	return db.queryStrings("SELECT tag FROM data_tag WHERE name = ? ORDER BY tag", name)
+}
+
+// requireItem returns an error unless name refers to an existing table or record
+func (db *DB) requireItem(name string) error {
+	var n int
+	err := db.QueryRow(
+		"SELECT (SELECT COUNT(*) FROM data_table WHERE name = ?) + (SELECT COUNT(*) FROM data_record WHERE name = ?)",
+		name, name,
+	).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	if n == 0 {
+		return notFound(name)
+	}
+	return nil
+}
+
+// queryStrings runs a query returning a single text column
+func (db *DB) queryStrings(query string, args ...any) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
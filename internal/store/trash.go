@@ -0,0 +1,255 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"srdm/internal/model"
+	"time"
+)
+
+// trashContent is everything needed to restore a deleted table or record
+// A table keeps its records, whose own tags, attributes and checksums come along
+type trashContent struct {
+	Table     *model.Table        `json:"table,omitempty"`
+	Record    *model.Record       `json:"record,omitempty"`
+	Schema    string              `json:"schema,omitempty"`
+	Checksums []model.Checksum    `json:"checksums,omitempty"`
+	Lineage   []model.LineageEdge `json:"lineage,omitempty"`
+}
+
+// Trash lists the deleted tables and records, most recently deleted first
+func (db *DB) Trash() ([]model.TrashItem, error) {
+	rows, err := db.Query(`
+	SELECT id, name, kind, records, user, deleted_at FROM data_trash ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	var items []model.TrashItem
+	for rows.Next() {
+		var it model.TrashItem
+		if err := rows.Scan(&it.ID, &it.Name, &it.Kind, &it.Records, &it.User, &it.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trash: %w", err)
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// Restore brings a deleted table or record back from the trash
+// id picks one of several trashed items of the same name; 0 takes the most recent.
+// A table comes back with its records, schema, checksums and lineage.
+// Fails with ErrNotFound if the item is not in the trash or a record's table is missing,
+// and with ErrAlreadyExists if the name has been reused since
+func (db *DB) Restore(name string, id int64) error {
+	return db.withTx(func(tx *DB) error {
+		return tx.restore(name, id)
+	})
+}
+
+// restore restores one trash entry within the caller's transaction
+func (db *DB) restore(name string, id int64) error {
+	query := "SELECT id, content FROM data_trash WHERE name = ? ORDER BY id DESC LIMIT 1"
+	args := []any{name}
+	if id > 0 {
+		query = "SELECT id, content FROM data_trash WHERE name = ? AND id = ?"
+		args = append(args, id)
+	}
+	var data string
+	err := db.QueryRow(query, args...).Scan(&id, &data)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s in trash", ErrNotFound, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read trash: %w", err)
+	}
+	var c trashContent
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return fmt.Errorf("failed to decode trash entry %d: %w", id, err)
+	}
+
+	if err := db.requireItem(name); err == nil {
+		return fmt.Errorf("cannot restore %s: %w", name, ErrAlreadyExists)
+	} else if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	switch {
+	case c.Table != nil:
+		records := c.Table.Records
+		c.Table.Records = nil
+		if err := db.trackChange(name, model.ActionRestore, func(tx *DB) error {
+			return tx.insertTable(c.Table)
+		}); err != nil {
+			return err
+		}
+		for i := range records {
+			r := &records[i]
+			if err := db.trackChange(r.FullName(), model.ActionRestore, func(tx *DB) error {
+				return tx.insertRecord(r)
+			}); err != nil {
+				return err
+			}
+		}
+		// The schema comes back last so it does not reject the records it was deleted with
+		if c.Schema != "" {
+			if _, err := db.Exec("INSERT INTO data_schema (table_name, rules) VALUES (?, ?)", name, c.Schema); err != nil {
+				return fmt.Errorf("failed to restore schema of %s: %w", name, err)
+			}
+		}
+	case c.Record != nil:
+		if err := db.trackChange(name, model.ActionRestore, func(tx *DB) error {
+			return tx.insertRecord(c.Record)
+		}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("trash entry %d of %s is empty", id, name)
+	}
+
+	for i := range c.Checksums {
+		if err := db.SaveChecksum(&c.Checksums[i]); err != nil {
+			return err
+		}
+	}
+	// Edges to items deleted in the meantime stay gone
+	for _, e := range c.Lineage {
+		ends := []string{e.Name}
+		if e.Relation == model.RelationDerivedFrom {
+			ends = append(ends, e.Upstream)
+		}
+		missing := false
+		for _, n := range ends {
+			if err := db.requireItem(n); errors.Is(err, ErrNotFound) {
+				missing = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if missing {
+			continue
+		}
+		if _, err := db.Exec(
+			"INSERT OR IGNORE INTO data_lineage (name, upstream, relation, create_at) VALUES (?, ?, ?, ?)",
+			e.Name, e.Upstream, e.Relation, e.CreateAt,
+		); err != nil {
+			return fmt.Errorf("failed to restore lineage of %s: %w", name, err)
+		}
+	}
+
+	if _, err := db.Exec("DELETE FROM data_trash WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to remove trash entry %d: %w", id, err)
+	}
+	return nil
+}
+
+// PurgeTrash permanently removes the items deleted before the given time
+// It returns the number of purged trash entries
+func (db *DB) PurgeTrash(before time.Time) (int, error) {
+	// Times are compared as instants; deleted_at may carry any zone or none (local time)
+	res, err := db.Exec(
+		"DELETE FROM data_trash WHERE julianday(deleted_at, 'utc') < julianday(?, 'utc')",
+		before.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return int(n), nil
+}
+
+// trashTable saves a table with its records, schema, checksums and lineage to the trash
+// t must have been loaded with GetTable
+func (db *DB) trashTable(t *model.Table) error {
+	name := t.FullName()
+	rules, err := db.GetSchema(name)
+	if err != nil {
+		return err
+	}
+	c := trashContent{Table: t, Schema: rules}
+	if c.Checksums, c.Lineage, err = db.trashRelations(name, true); err != nil {
+		return err
+	}
+	return db.saveTrash(name, model.KindTable, len(t.Records), &c)
+}
+
+// trashRecord saves a record with its checksums and lineage to the trash
+func (db *DB) trashRecord(r *model.Record) error {
+	c := trashContent{Record: r}
+	var err error
+	if c.Checksums, c.Lineage, err = db.trashRelations(r.FullName(), false); err != nil {
+		return err
+	}
+	return db.saveTrash(r.FullName(), model.KindRecord, 0, &c)
+}
+
+// saveTrash appends one trash entry
+func (db *DB) saveTrash(name, kind string, records int, c *trashContent) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s for the trash: %w", name, err)
+	}
+	if _, err := db.Exec(
+		"INSERT INTO data_trash (name, kind, records, content, user, deleted_at) VALUES (?, ?, ?, ?, ?, ?)",
+		name, kind, records, string(data), currentUser(), time.Now(),
+	); err != nil {
+		return fmt.Errorf("failed to move %s to the trash: %w", name, err)
+	}
+	return nil
+}
+
+// matchItem returns a condition on col selecting the name ?1 and, when ?2 is true,
+// the names of the records of table ?1. Unlike LIKE it treats _ and case literally
+func matchItem(col string) string {
+	return fmt.Sprintf("(%[1]s = ?1 OR (?2 AND substr(%[1]s, 1, length(?1) + 1) = ?1 || ':'))", col)
+}
+
+// trashRelations returns the checksums of an item and the lineage edges touching it
+// For a table the checksums and edges of its records are included
+func (db *DB) trashRelations(name string, table bool) ([]model.Checksum, []model.LineageEdge, error) {
+	rows, err := db.Query(`
+	SELECT name, field, path, size, mtime, sha256, checked_at
+	FROM data_checksum WHERE `+matchItem("name")+` ORDER BY name, field
+	`, name, table)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query checksums: %w", err)
+	}
+	defer rows.Close()
+	var sums []model.Checksum
+	for rows.Next() {
+		var c model.Checksum
+		if err := rows.Scan(&c.Name, &c.Field, &c.Path, &c.Size, &c.ModTime, &c.SHA256, &c.CheckedAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan checksum: %w", err)
+		}
+		sums = append(sums, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	edgeRows, err := db.Query(`
+	SELECT name, upstream, relation, create_at FROM data_lineage
+	WHERE `+matchItem("name")+` OR `+matchItem("upstream")+`
+	ORDER BY name, relation, upstream
+	`, name, table)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query lineage: %w", err)
+	}
+	defer edgeRows.Close()
+	var edges []model.LineageEdge
+	for edgeRows.Next() {
+		var e model.LineageEdge
+		if err := edgeRows.Scan(&e.Name, &e.Upstream, &e.Relation, &e.CreateAt); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan lineage: %w", err)
+		}
+		edges = append(edges, e)
+	}
+	return sums, edges, edgeRows.Err()
+}
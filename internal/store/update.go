@@ -7,6 +7,13 @@ import (
 
 // UpdateTable updates table information
 func (db *DB) UpdateTable(t *model.Table) error {
+	return db.trackChange(t.FullName(), model.ActionUpdate, func(tx *DB) error {
+		return tx.updateTable(t)
+	})
+}
+
+// updateTable writes the table row, its tags and attributes without recording history
+func (db *DB) updateTable(t *model.Table) error {
 	query := `
 	UPDATE data_table SET 
 		keys = ?, path = ?, engine = ?, source = ?, description = ?,
@@ -26,13 +33,26 @@ func (db *DB) UpdateTable(t *model.Table) error {
 	// Check if any row updated
 	rows, _ := res.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("table not found: %s", t.FullName())
+		return notFound(t.FullName())
 	}
-	return nil
+	if err := db.setTags(t.FullName(), t.Tags); err != nil {
+		return err
+	}
+	return db.setAttributes(t.FullName(), t.Attributes)
 }
 
 // UpdateRecord updates record information
 func (db *DB) UpdateRecord(r *model.Record) error {
+	return db.trackChange(r.FullName(), model.ActionUpdate, func(tx *DB) error {
+		return tx.updateRecord(r)
+	})
+}
+
+// updateRecord writes the record row, its tags and attributes without recording history
+func (db *DB) updateRecord(r *model.Record) error {
+	if err := db.checkSchema(r); err != nil {
+		return err
+	}
 	query := `
 	UPDATE data_record SET 
 		type = ?, source = ?, label = ?, description = ?,
@@ -53,7 +73,10 @@ func (db *DB) UpdateRecord(r *model.Record) error {
 
 	rows, _ := res.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("record not found: %s", r.FullName())
+		return notFound(r.FullName())
+	}
+	if err := db.setTags(r.FullName(), r.Tags); err != nil {
+		return err
 	}
-	return nil
+	return db.setAttributes(r.FullName(), r.Attributes)
 }